@@ -0,0 +1,154 @@
+// Package apikey issues, verifies and revokes API keys for machine
+// clients. Only a SHA-256 hash of each key is stored; the key itself is
+// shown once, when it is issued.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// lastUsedResolution bounds how often a key's last-used time is written,
+// so busy clients do not cost a database write per request.
+const lastUsedResolution = time.Minute
+
+var (
+	ErrNotFound      = errors.New("api key not found")
+	ErrInvalidName   = errors.New("name is required")
+	ErrInvalidScopes = errors.New("scopes must list at least one of create, read, update, delete")
+)
+
+// Key is a stored API key.
+type Key struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	Name       string             `bson:"name" json:"name"`
+	Hash       string             `bson:"hash" json:"-"`
+	Scopes     []auth.Operation   `bson:"scopes" json:"scopes"`
+	CreatedBy  string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// Store persists API keys.
+type Store interface {
+	Insert(ctx context.Context, key Key) error
+	// FindByHash returns ErrNotFound when no key has hash.
+	FindByHash(ctx context.Context, hash string) (*Key, error)
+	List(ctx context.Context) ([]Key, error)
+	// Revoke returns ErrNotFound when the key does not exist or is
+	// already revoked.
+	Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
+
+// Manager issues and verifies keys. It implements auth.KeyVerifier.
+type Manager struct {
+	Store Store
+	now   func() time.Time
+}
+
+func NewManager(store Store) *Manager {
+	return &Manager{Store: store, now: time.Now}
+}
+
+// Issue creates a key named name, limited to scopes, and returns it in
+// plain text together with its stored form.
+func (m *Manager) Issue(ctx context.Context, name string, scopes []auth.Operation, createdBy string) (string, *Key, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, ErrInvalidName
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, fmt.Errorf("generate api key: %w", err)
+	}
+	token := auth.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	key := Key{
+		ID:        primitive.NewObjectID(),
+		Name:      name,
+		Hash:      Hash(token),
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		CreatedAt: m.now().UTC(),
+	}
+	if err := m.Store.Insert(ctx, key); err != nil {
+		return "", nil, err
+	}
+	return token, &key, nil
+}
+
+// VerifyKey resolves token to the claims of its key and records its use.
+// Unknown and revoked keys are reported as auth.ErrInvalidToken. A failure
+// to record the use is logged, since the key is still valid.
+func (m *Manager) VerifyKey(ctx context.Context, token string) (*auth.Claims, error) {
+	key, err := m.Store.FindByHash(ctx, Hash(token))
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown api key", auth.ErrInvalidToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("%w: revoked api key", auth.ErrInvalidToken)
+	}
+
+	now := m.now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := m.Store.MarkUsed(ctx, key.ID, now); err != nil {
+			logging.FromContext(ctx).Error("api key last use not recorded", "key_id", key.ID.Hex(), "error", err)
+		}
+	}
+	claims := &auth.Claims{Scopes: key.Scopes}
+	claims.Subject = "apikey:" + key.ID.Hex()
+	return claims, nil
+}
+
+func (m *Manager) List(ctx context.Context) ([]Key, error) {
+	return m.Store.List(ctx)
+}
+
+func (m *Manager) Revoke(ctx context.Context, id primitive.ObjectID) error {
+	return m.Store.Revoke(ctx, id, m.now().UTC())
+}
+
+// Hash returns the stored form of token.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeScopes(scopes []auth.Operation) ([]auth.Operation, error) {
+	var out []auth.Operation
+	for _, op := range auth.Operations {
+		if slices.Contains(scopes, op) {
+			out = append(out, op)
+		}
+	}
+	for _, op := range scopes {
+		if !slices.Contains(auth.Operations, op) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidScopes, op)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrInvalidScopes
+	}
+	return out, nil
+}
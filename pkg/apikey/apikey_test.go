@@ -0,0 +1,181 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestManager(now *time.Time) (*Manager, *MemoryStore) {
+	store := NewMemoryStore()
+	m := NewManager(store)
+	m.now = func() time.Time { return *now }
+	return m, store
+}
+
+func TestManager_IssueStoresOnlyTheHash(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	m, store := newTestManager(&now)
+
+	// Act
+	token, key, err := m.Issue(context.Background(), "batch-import", []auth.Operation{auth.OpRead, auth.OpCreate}, "ana")
+
+	// Assert - Regla de negocio: La clave solo se muestra al emitirla; se guarda su hash
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, auth.APIKeyPrefix))
+	assert.Equal(t, []auth.Operation{auth.OpCreate, auth.OpRead}, key.Scopes)
+	stored, err := store.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, Hash(token), stored[0].Hash)
+	assert.NotContains(t, stored[0].Hash, token)
+	assert.Equal(t, "ana", stored[0].CreatedBy)
+	assert.Equal(t, now, stored[0].CreatedAt)
+}
+
+func TestManager_IssueValidates(t *testing.T) {
+	now := time.Now()
+	m, _ := newTestManager(&now)
+
+	_, _, noName := m.Issue(context.Background(), " ", []auth.Operation{auth.OpRead}, "")
+	_, _, noScopes := m.Issue(context.Background(), "job", nil, "")
+	_, _, badScope := m.Issue(context.Background(), "job", []auth.Operation{"purge"}, "")
+
+	assert.ErrorIs(t, noName, ErrInvalidName)
+	assert.ErrorIs(t, noScopes, ErrInvalidScopes)
+	assert.ErrorIs(t, badScope, ErrInvalidScopes)
+}
+
+func TestManager_VerifyKey(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	m, store := newTestManager(&now)
+	token, key, err := m.Issue(context.Background(), "job", []auth.Operation{auth.OpCreate}, "")
+	require.NoError(t, err)
+
+	// Act
+	claims, err := m.VerifyKey(context.Background(), token)
+	_, unknown := m.VerifyKey(context.Background(), auth.APIKeyPrefix+"nope")
+
+	// Assert - Regla de negocio: Una clave válida da acceso a su alcance y registra su uso
+	require.NoError(t, err)
+	assert.True(t, claims.Allows(auth.OpCreate))
+	assert.False(t, claims.Allows(auth.OpDelete))
+	assert.Equal(t, "apikey:"+key.ID.Hex(), claims.Subject)
+	assert.ErrorIs(t, unknown, auth.ErrInvalidToken)
+	stored, _ := store.FindByHash(context.Background(), Hash(token))
+	require.NotNil(t, stored.LastUsedAt)
+	assert.Equal(t, now, *stored.LastUsedAt)
+}
+
+func TestManager_LastUsedIsThrottled(t *testing.T) {
+	// Arrange
+	start := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	now := start
+	m, store := newTestManager(&now)
+	token, _, err := m.Issue(context.Background(), "job", []auth.Operation{auth.OpRead}, "")
+	require.NoError(t, err)
+
+	// Act
+	_, _ = m.VerifyKey(context.Background(), token)
+	now = start.Add(30 * time.Second)
+	_, _ = m.VerifyKey(context.Background(), token)
+	afterBurst, _ := store.FindByHash(context.Background(), Hash(token))
+	now = start.Add(2 * time.Minute)
+	_, _ = m.VerifyKey(context.Background(), token)
+	later, _ := store.FindByHash(context.Background(), Hash(token))
+
+	// Assert - Regla de negocio: La fecha de último uso se actualiza como mucho una vez por minuto
+	assert.Equal(t, start, *afterBurst.LastUsedAt)
+	assert.Equal(t, start.Add(2*time.Minute), *later.LastUsedAt)
+}
+
+type failingMarkStore struct{ *MemoryStore }
+
+func (failingMarkStore) MarkUsed(context.Context, primitive.ObjectID, time.Time) error {
+	return errors.New("error de conexión")
+}
+
+func TestManager_VerifyKeyWhenUseIsNotRecorded(t *testing.T) {
+	// Arrange
+	m := NewManager(failingMarkStore{NewMemoryStore()})
+	token, _, err := m.Issue(context.Background(), "job", []auth.Operation{auth.OpRead}, "")
+	require.NoError(t, err)
+
+	// Act
+	claims, err := m.VerifyKey(context.Background(), token)
+
+	// Assert - Regla de negocio: No poder registrar el uso no impide autenticar una clave válida
+	require.NoError(t, err)
+	assert.True(t, claims.Allows(auth.OpRead))
+}
+
+func TestStores_ListEmptyAsArray(t *testing.T) {
+	// Arrange
+	file, err := memstore.OpenFile(filepath.Join(t.TempDir(), "store.bson"))
+	require.NoError(t, err)
+
+	// Act
+	local, localErr := NewMemoryStore().List(context.Background())
+	shared, sharedErr := NewSharedMemoryStore(file).List(context.Background())
+
+	// Assert - Regla de negocio: Sin claves la lista es un arreglo vacío, no null
+	require.NoError(t, localErr)
+	require.NoError(t, sharedErr)
+	assert.NotNil(t, local)
+	assert.NotNil(t, shared)
+	assert.Empty(t, shared)
+}
+
+func TestManager_Revoke(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	m, _ := newTestManager(&now)
+	token, key, err := m.Issue(context.Background(), "job", []auth.Operation{auth.OpRead}, "")
+	require.NoError(t, err)
+
+	// Act
+	err = m.Revoke(context.Background(), key.ID)
+	_, verifyErr := m.VerifyKey(context.Background(), token)
+	again := m.Revoke(context.Background(), key.ID)
+
+	// Assert - Regla de negocio: Una clave revocada deja de ser aceptada
+	require.NoError(t, err)
+	assert.ErrorIs(t, verifyErr, auth.ErrInvalidToken)
+	assert.ErrorIs(t, again, ErrNotFound)
+}
+
+func TestSharedMemoryStore_KeysSeenByEveryService(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "store.bson")
+	issuerFile, err := memstore.OpenFile(path)
+	require.NoError(t, err)
+	verifierFile, err := memstore.OpenFile(path)
+	require.NoError(t, err)
+	issuer := NewManager(NewSharedMemoryStore(issuerFile))
+	verifier := NewManager(NewSharedMemoryStore(verifierFile))
+	ctx := context.Background()
+	token, key, err := issuer.Issue(ctx, "batch-import", []auth.Operation{auth.OpCreate}, "admin")
+	require.NoError(t, err)
+
+	// Act
+	claims, verifyErr := verifier.VerifyKey(ctx, token)
+	revokeErr := verifier.Revoke(ctx, key.ID)
+	_, revokedErr := issuer.VerifyKey(ctx, token)
+
+	// Assert - Regla de negocio: Una clave emitida o revocada en un servicio vale igual en los demás
+	require.NoError(t, verifyErr)
+	assert.True(t, claims.Allows(auth.OpCreate))
+	assert.NoError(t, revokeErr)
+	assert.Error(t, revokedErr)
+}
@@ -0,0 +1,109 @@
+package apikey
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AdminPath is where the admin endpoints are mounted.
+const AdminPath = "/admin/api-keys"
+
+type issueRequest struct {
+	Name   string           `json:"name"`
+	Scopes []auth.Operation `json:"scopes"`
+}
+
+// issueResponse is the only response that carries the key itself.
+type issueResponse struct {
+	*Key
+	Token string `json:"key"`
+}
+
+// NewAdminHandler serves GET and POST on /admin/api-keys and DELETE on
+// /admin/api-keys/{id}. Callers are expected to be authorized already.
+func NewAdminHandler(m *Manager) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(AdminPath, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			keys, err := m.List(r.Context())
+			if err != nil {
+				internalError(w, r, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, keys)
+		case http.MethodPost:
+			var req issueRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, server.BodyErrorStatus(err), err.Error())
+				return
+			}
+			var createdBy string
+			if c, ok := auth.ClaimsFromContext(r.Context()); ok {
+				createdBy = c.Subject
+			}
+			token, key, err := m.Issue(r.Context(), req.Name, req.Scopes, createdBy)
+			if errors.Is(err, ErrInvalidName) || errors.Is(err, ErrInvalidScopes) {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if err != nil {
+				internalError(w, r, err)
+				return
+			}
+			w.Header().Set("Location", AdminPath+"/"+key.ID.Hex())
+			writeJSON(w, http.StatusCreated, issueResponse{Key: key, Token: token})
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc(AdminPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.Header().Set("Allow", "DELETE")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		id, err := primitive.ObjectIDFromHex(strings.TrimPrefix(r.URL.Path, AdminPath+"/"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid id format")
+			return
+		}
+		err = m.Revoke(r.Context(), id)
+		if errors.Is(err, ErrNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+func internalError(w http.ResponseWriter, r *http.Request, err error) {
+	logging.FromContext(r.Context()).Error("api key admin", "error", err)
+	writeError(w, http.StatusInternalServerError, "api key error: "+err.Error())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
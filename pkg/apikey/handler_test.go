@@ -0,0 +1,47 @@
+package apikey
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler_IssueListRevoke(t *testing.T) {
+	// Arrange
+	m := NewManager(NewMemoryStore())
+	handler := NewAdminHandler(m)
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	// Act
+	issued := serve(http.MethodPost, AdminPath, `{"name":"batch-import","scopes":["create"]}`)
+	var created struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	require.NoError(t, json.Unmarshal(issued.Body.Bytes(), &created))
+	listed := serve(http.MethodGet, AdminPath, "")
+	revoked := serve(http.MethodDelete, AdminPath+"/"+created.ID, "")
+	missing := serve(http.MethodDelete, AdminPath+"/"+created.ID, "")
+	invalid := serve(http.MethodPost, AdminPath, `{"name":"job","scopes":["purge"]}`)
+
+	// Assert - Regla de negocio: Las claves se emiten, se listan sin secreto y se revocan
+	assert.Equal(t, http.StatusCreated, issued.Code)
+	assert.Equal(t, AdminPath+"/"+created.ID, issued.Header().Get("Location"))
+	assert.NotEmpty(t, created.Key)
+	assert.Equal(t, http.StatusOK, listed.Code)
+	assert.Contains(t, listed.Body.String(), `"batch-import"`)
+	assert.NotContains(t, listed.Body.String(), created.Key, "El listado nunca incluye la clave")
+	assert.NotContains(t, listed.Body.String(), "hash")
+	assert.Equal(t, http.StatusNoContent, revoked.Code)
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+}
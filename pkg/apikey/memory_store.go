@@ -0,0 +1,118 @@
+package apikey
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const keysField = "api_keys"
+
+// MemoryStore keeps keys in process memory, for the memory storage
+// backend and tests. A shared store keeps them in a memstore.File instead,
+// so every service sees the keys issued by the create service.
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys []Key
+	file *memstore.File
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// NewSharedMemoryStore returns a store kept in file, or in process memory
+// when file is nil.
+func NewSharedMemoryStore(file *memstore.File) *MemoryStore {
+	return &MemoryStore{file: file}
+}
+
+func (s *MemoryStore) Insert(_ context.Context, key Key) error {
+	return s.sync(func() bool {
+		s.keys = append(s.keys, key)
+		return true
+	})
+}
+
+func (s *MemoryStore) FindByHash(_ context.Context, hash string) (found *Key, err error) {
+	err = s.sync(func() bool {
+		for _, k := range s.keys {
+			if k.Hash == hash {
+				found = &k
+				return false
+			}
+		}
+		return false
+	})
+	if err == nil && found == nil {
+		err = ErrNotFound
+	}
+	return found, err
+}
+
+// List returns an empty slice rather than nil when there are no keys, as
+// MongoStore does, so both encode as a JSON array.
+func (s *MemoryStore) List(_ context.Context) ([]Key, error) {
+	keys := []Key{}
+	err := s.sync(func() bool {
+		keys = append(keys, s.keys...)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *MemoryStore) Revoke(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	return s.update(id, func(k *Key) bool {
+		if k.RevokedAt != nil {
+			return false
+		}
+		k.RevokedAt = &at
+		return true
+	})
+}
+
+func (s *MemoryStore) MarkUsed(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	err := s.update(id, func(k *Key) bool {
+		k.LastUsedAt = &at
+		return true
+	})
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (s *MemoryStore) update(id primitive.ObjectID, fn func(*Key) bool) error {
+	found := false
+	err := s.sync(func() bool {
+		for i := range s.keys {
+			if s.keys[i].ID == id && fn(&s.keys[i]) {
+				found = true
+				return true
+			}
+		}
+		return false
+	})
+	if err == nil && !found {
+		err = ErrNotFound
+	}
+	return err
+}
+
+// sync runs fn on the keys, reloading them from the shared file first and
+// saving them back when fn returns true.
+func (s *MemoryStore) sync(fn func() bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		fn()
+		return nil
+	}
+	return s.file.Sync(keysField, &s.keys, fn)
+}
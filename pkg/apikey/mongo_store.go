@@ -0,0 +1,79 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection holds the API keys.
+const Collection = "api_keys"
+
+type MongoStore struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{Collection: db.Collection(Collection)}
+}
+
+// EnsureIndexes creates the unique index keys are looked up by.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (s *MongoStore) Insert(ctx context.Context, key Key) error {
+	_, err := s.Collection.InsertOne(ctx, key)
+	return err
+}
+
+func (s *MongoStore) FindByHash(ctx context.Context, hash string) (*Key, error) {
+	var key Key
+	err := s.Collection.FindOne(ctx, bson.M{"hash": hash}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *MongoStore) List(ctx context.Context) ([]Key, error) {
+	cursor, err := s.Collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	keys := []Key{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *MongoStore) Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	res, err := s.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": at}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	_, err := s.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"last_used_at": at}})
+	return err
+}
@@ -0,0 +1,164 @@
+// Package audit records who changed which product, when and how. Every
+// create, update, rollback, delete and restore adds an entry with the
+// changed fields' values before and after the write; a purge adds one
+// with the product's last version.
+package audit
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionRollback = "rollback"
+	ActionDelete   = "delete"
+	ActionRestore  = "restore"
+	ActionPurge    = "purge"
+)
+
+const (
+	// Anonymous is the actor of writes made without authentication.
+	Anonymous = "anonymous"
+	// System is the actor of writes made by background jobs.
+	System = "system"
+)
+
+// Change is one field's value before and after a write, using the field's
+// JSON name. Before is null for a create.
+type Change struct {
+	Field  string `bson:"field" json:"field"`
+	Before any    `bson:"before" json:"before"`
+	After  any    `bson:"after" json:"after"`
+}
+
+type Entry struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	Action    string             `bson:"action" json:"action"`
+	Actor     string             `bson:"actor" json:"actor"`
+	RequestID string             `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	At        time.Time          `bson:"at" json:"at"`
+	// Version is the product's version after the write, when known.
+	Version int64    `bson:"version,omitempty" json:"version,omitempty"`
+	Changes []Change `bson:"changes" json:"changes"`
+}
+
+// Store persists entries.
+type Store interface {
+	Insert(ctx context.Context, entries ...Entry) error
+	// History returns up to limit entries of product, newest first,
+	// starting after the entry before when it is not zero.
+	History(ctx context.Context, productID, before primitive.ObjectID, limit int) ([]Entry, error)
+}
+
+// Mutation describes one write to record.
+type Mutation struct {
+	Action    string
+	ProductID primitive.ObjectID
+	Version   int64
+	Changes   []Change
+	// Actor overrides the actor taken from the request context.
+	Actor string
+}
+
+// Changed describes a write from the product before and after it. before
+// is nil for a create.
+func Changed(action string, before, after *model.Product) Mutation {
+	m := Mutation{Action: action, ProductID: after.ID, Version: after.Version, Changes: Diff(before, after)}
+	if m.ProductID.IsZero() && before != nil {
+		m.ProductID = before.ID
+	}
+	return m
+}
+
+// Diff lists the fields that differ between before and after, except the
+// ID and version. A nil before lists every field.
+func Diff(before, after *model.Product) []Change {
+	changes := []Change{}
+	a := reflect.ValueOf(*after)
+	var b reflect.Value
+	if before != nil {
+		b = reflect.ValueOf(*before)
+	}
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "id" || name == "version" {
+			continue
+		}
+		change := Change{Field: name, After: value(a.Field(i))}
+		if before != nil {
+			if reflect.DeepEqual(b.Field(i).Interface(), a.Field(i).Interface()) {
+				continue
+			}
+			change.Before = value(b.Field(i))
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// value unwraps pointers so a cleared field is stored as null.
+func value(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// Recorder writes entries for mutations, filling in the actor and request
+// ID from the request context.
+type Recorder struct {
+	Store Store
+	now   func() time.Time
+}
+
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{Store: store, now: time.Now}
+}
+
+// Record writes one entry per mutation. The product write has already
+// happened, so an error means the change is stored but unaudited; callers
+// return it so the request fails rather than going unrecorded. A nil
+// Recorder records nothing.
+func (r *Recorder) Record(ctx context.Context, mutations ...Mutation) error {
+	if r == nil || len(mutations) == 0 {
+		return nil
+	}
+	actor := Anonymous
+	if c, ok := auth.ClaimsFromContext(ctx); ok && c.Subject != "" {
+		actor = c.Subject
+	}
+	at := r.now().UTC()
+	entries := make([]Entry, len(mutations))
+	for i, m := range mutations {
+		entries[i] = Entry{
+			ID:        primitive.NewObjectID(),
+			ProductID: m.ProductID,
+			Action:    m.Action,
+			Actor:     cmp.Or(m.Actor, actor),
+			RequestID: logging.RequestID(ctx),
+			At:        at,
+			Version:   m.Version,
+			Changes:   m.Changes,
+		}
+	}
+	if err := r.Store.Insert(ctx, entries...); err != nil {
+		return fmt.Errorf("record audit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiff_ListsChangedFields(t *testing.T) {
+	// Arrange
+	at := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	before := &model.Product{ID: primitive.NewObjectID(), Name: "Laptop", Price: 999, Stock: 5, Version: 3}
+	after := *before
+	after.Price, after.Stock, after.Version, after.DeletedAt = 899, 4, 4, &at
+
+	// Act
+	changes := Diff(before, &after)
+
+	// Assert - Regla de negocio: Solo se registran los campos modificados con su valor anterior
+	assert.Equal(t, []Change{
+		{Field: "price", Before: 999.0, After: 899.0},
+		{Field: "stock", Before: 5, After: 4},
+		{Field: "deleted_at", Before: nil, After: at},
+	}, changes)
+}
+
+func TestDiff_CreateListsEveryField(t *testing.T) {
+	changes := Diff(nil, &model.Product{Name: "Laptop", Price: 999})
+
+	assert.Equal(t, []Change{
+		{Field: "name", After: "Laptop"},
+		{Field: "description", After: ""},
+		{Field: "price", After: 999.0},
+		{Field: "stock", After: 0},
+		{Field: "deleted_at", After: nil},
+	}, changes)
+}
+
+func TestRecorder_FillsActorAndRequestID(t *testing.T) {
+	// Arrange
+	store := NewMemoryStore()
+	rec := NewRecorder(store)
+	at := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	rec.now = func() time.Time { return at }
+	product := &model.Product{ID: primitive.NewObjectID(), Name: "Laptop", Version: 1}
+
+	var ctx context.Context
+	handler := logging.Middleware(logging.NewWithWriter(io.Discard, "test", "error"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { ctx = r.Context() }))
+	req := httptest.NewRequest(http.MethodPost, "/products", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-7")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	claims := &auth.Claims{}
+	claims.Subject = "ana"
+	ctx = auth.WithClaims(ctx, claims)
+
+	// Act
+	rec.Record(ctx, Changed(ActionCreate, nil, product))
+
+	// Assert - Regla de negocio: Cada cambio registra quién, cuándo y en qué petición
+	entries, err := store.History(context.Background(), product.ID, primitive.NilObjectID, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ActionCreate, entries[0].Action)
+	assert.Equal(t, "ana", entries[0].Actor)
+	assert.Equal(t, "req-7", entries[0].RequestID)
+	assert.Equal(t, at, entries[0].At)
+	assert.Equal(t, int64(1), entries[0].Version)
+}
+
+func TestRecorder_AnonymousAndNil(t *testing.T) {
+	store := NewMemoryStore()
+	id := primitive.NewObjectID()
+	NewRecorder(store).Record(context.Background(), Mutation{Action: ActionDelete, ProductID: id})
+	var nilRecorder *Recorder
+	nilRecorder.Record(context.Background(), Mutation{Action: ActionDelete, ProductID: id})
+
+	entries, _ := store.History(context.Background(), id, primitive.NilObjectID, 10)
+	require.Len(t, entries, 1)
+	assert.Equal(t, Anonymous, entries[0].Actor)
+}
+
+func TestRecorder_ActorOverride(t *testing.T) {
+	// Arrange
+	store := NewMemoryStore()
+	id := primitive.NewObjectID()
+	claims := &auth.Claims{}
+	claims.Subject = "ana"
+	ctx := auth.WithClaims(context.Background(), claims)
+
+	// Act
+	NewRecorder(store).Record(ctx, Mutation{Action: ActionPurge, ProductID: id, Version: 3, Actor: System})
+
+	// Assert - Regla de negocio: Los trabajos en segundo plano se registran como "system"
+	entries, err := store.History(context.Background(), id, primitive.NilObjectID, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, System, entries[0].Actor)
+	assert.Equal(t, int64(3), entries[0].Version)
+}
+
+type failingStore struct{ MemoryStore }
+
+func (*failingStore) Insert(context.Context, ...Entry) error { return errors.New("error de conexión") }
+
+func TestRecorder_ReturnsStoreFailure(t *testing.T) {
+	// Act
+	err := NewRecorder(&failingStore{}).Record(context.Background(), Mutation{Action: ActionUpdate})
+	nilErr := (*Recorder)(nil).Record(context.Background(), Mutation{Action: ActionUpdate})
+
+	// Assert - Regla de negocio: Un fallo al auditar se devuelve para que la petición no quede sin registrar
+	assert.ErrorContains(t, err, "error de conexión")
+	assert.NoError(t, nilErr)
+}
+
+func TestMemoryStore_HistoryNewestFirstWithCursor(t *testing.T) {
+	// Arrange
+	store := NewMemoryStore()
+	id, other := primitive.NewObjectID(), primitive.NewObjectID()
+	rec := NewRecorder(store)
+	for i := 1; i <= 3; i++ {
+		rec.Record(context.Background(), Mutation{Action: ActionUpdate, ProductID: id, Version: int64(i)})
+		rec.Record(context.Background(), Mutation{Action: ActionUpdate, ProductID: other, Version: int64(i)})
+	}
+
+	// Act
+	first, _ := store.History(context.Background(), id, primitive.NilObjectID, 2)
+	rest, _ := store.History(context.Background(), id, first[1].ID, 2)
+
+	// Assert - Regla de negocio: El historial se recorre del cambio más reciente al más antiguo
+	require.Len(t, first, 2)
+	assert.Equal(t, []int64{3, 2}, []int64{first[0].Version, first[1].Version})
+	require.Len(t, rest, 1)
+	assert.Equal(t, int64(1), rest[0].Version)
+}
+
+func TestSharedMemoryStore_HistorySeenByEveryService(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "store.bson")
+	writerFile, err := memstore.OpenFile(path)
+	require.NoError(t, err)
+	readerFile, err := memstore.OpenFile(path)
+	require.NoError(t, err)
+	writer, reader := NewSharedMemoryStore(writerFile), NewSharedMemoryStore(readerFile)
+	product := &model.Product{ID: primitive.NewObjectID(), Name: "Laptop", Version: 1}
+
+	// Act
+	NewRecorder(writer).Record(context.Background(), Changed(ActionCreate, nil, product))
+	entries, err := reader.History(context.Background(), product.ID, primitive.NilObjectID, 10)
+
+	// Assert - Regla de negocio: El historial escrito por un servicio se lee desde otro
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ActionCreate, entries[0].Action)
+	assert.Equal(t, int64(1), entries[0].Version)
+}
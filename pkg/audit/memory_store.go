@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const entriesField = "audit"
+
+// MemoryStore keeps entries in process memory, for the memory storage
+// backend and tests. A shared store keeps them in a memstore.File instead,
+// so the read service sees the entries written by the other services.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []Entry
+	file    *memstore.File
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// NewSharedMemoryStore returns a store kept in file, or in process memory
+// when file is nil.
+func NewSharedMemoryStore(file *memstore.File) *MemoryStore {
+	return &MemoryStore{file: file}
+}
+
+func (s *MemoryStore) Insert(_ context.Context, entries ...Entry) error {
+	return s.sync(func() bool {
+		s.entries = append(s.entries, entries...)
+		return true
+	})
+}
+
+func (s *MemoryStore) History(_ context.Context, productID, before primitive.ObjectID, limit int) ([]Entry, error) {
+	out := []Entry{}
+	err := s.sync(func() bool {
+		for i := len(s.entries) - 1; i >= 0 && len(out) < limit; i-- {
+			e := s.entries[i]
+			if e.ProductID != productID {
+				continue
+			}
+			if !before.IsZero() && e.ID.Hex() >= before.Hex() {
+				continue
+			}
+			out = append(out, e)
+		}
+		return false
+	})
+	return out, err
+}
+
+// sync runs fn on the entries, reloading them from the shared file first
+// and saving them back when fn returns true.
+func (s *MemoryStore) sync(fn func() bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		fn()
+		return nil
+	}
+	return s.file.Sync(entriesField, &s.entries, fn)
+}
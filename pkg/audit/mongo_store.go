@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection holds the audit entries.
+const Collection = "audit"
+
+type MongoStore struct {
+	Collection *mongo.Collection
+}
+
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{Collection: db.Collection(Collection)}
+}
+
+// EnsureIndexes creates the index History reads through.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "_id", Value: -1}},
+	})
+	return err
+}
+
+func (s *MongoStore) Insert(ctx context.Context, entries ...Entry) error {
+	docs := make([]any, len(entries))
+	for i, e := range entries {
+		docs[i] = e
+	}
+	_, err := s.Collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (s *MongoStore) History(ctx context.Context, productID, before primitive.ObjectID, limit int) ([]Entry, error) {
+	filter := bson.M{"product_id": productID}
+	if !before.IsZero() {
+		filter["_id"] = bson.M{"$lt": before}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := s.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []Entry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
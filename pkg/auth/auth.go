@@ -0,0 +1,193 @@
+// Package auth authenticates requests with JWT bearer tokens or API keys
+// and authorizes operations: reads are public, creates and updates need
+// the editor role and deletes need the admin role. API keys are limited to
+// the operations they are scoped to instead.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Operation is what a request does to products.
+type Operation string
+
+const (
+	OpCreate Operation = "create"
+	OpRead   Operation = "read"
+	OpUpdate Operation = "update"
+	OpDelete Operation = "delete"
+)
+
+// Operations lists every operation, in CRUD order.
+var Operations = []Operation{OpCreate, OpRead, OpUpdate, OpDelete}
+
+// APIKeyPrefix starts every API key, which tells keys and JWTs apart in
+// an Authorization header.
+const APIKeyPrefix = "pak_"
+
+// Environment variables read by FromEnv.
+const (
+	SecretEnv   = "AUTH_JWT_SECRET"
+	JWKSFileEnv = "AUTH_JWKS_FILE"
+	IssuerEnv   = "AUTH_ISSUER"
+	AudienceEnv = "AUTH_AUDIENCE"
+	DisabledEnv = "AUTH_DISABLED"
+)
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+	ErrForbidden    = errors.New("insufficient permissions")
+)
+
+// Claims describe the authenticated caller. Roles come from a JWT; admin
+// implies editor. Scopes are set for API keys only and replace roles.
+type Claims struct {
+	Roles  []string    `json:"roles"`
+	Scopes []Operation `json:"-"`
+	jwt.RegisteredClaims
+}
+
+// KeyVerifier resolves an API key to the claims of its owner.
+type KeyVerifier interface {
+	VerifyKey(ctx context.Context, key string) (*Claims, error)
+}
+
+// HasRole reports whether the claims grant role.
+func (c *Claims) HasRole(role string) bool {
+	if slices.Contains(c.Roles, RoleAdmin) {
+		return true
+	}
+	return slices.Contains(c.Roles, role)
+}
+
+type contextKey struct{}
+
+// WithClaims returns a copy of ctx carrying the authenticated claims.
+func WithClaims(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// IsAPIKey reports whether the claims come from an API key.
+func (c *Claims) IsAPIKey() bool {
+	return c.Scopes != nil
+}
+
+// Allows reports whether the caller may perform op.
+func (c *Claims) Allows(op Operation) bool {
+	if c.IsAPIKey() {
+		return slices.Contains(c.Scopes, op)
+	}
+	switch op {
+	case OpRead:
+		return true
+	case OpCreate, OpUpdate:
+		return c.HasRole(RoleEditor)
+	default:
+		return c.HasRole(RoleAdmin)
+	}
+}
+
+// ClaimsFromContext returns the claims of the authenticated caller, if any.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(contextKey{}).(*Claims)
+	return c, ok
+}
+
+// Verifier validates tokens signed with HS256 using Secret or with RS256
+// using one of Keys, selected by the token's kid. API keys are accepted
+// when APIKeys is set.
+type Verifier struct {
+	Secret   []byte
+	Keys     KeySet
+	Issuer   string
+	Audience string
+	APIKeys  KeyVerifier
+}
+
+// FromEnv builds a Verifier from AUTH_JWT_SECRET and AUTH_JWKS_FILE, with
+// optional AUTH_ISSUER and AUTH_AUDIENCE checks. It returns nil when
+// AUTH_DISABLED is true, and an error when no key is configured.
+func FromEnv() (*Verifier, error) {
+	if v := os.Getenv(DisabledEnv); v != "" {
+		disabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", DisabledEnv, err)
+		}
+		if disabled {
+			return nil, nil
+		}
+	}
+	v := &Verifier{
+		Secret:   []byte(os.Getenv(SecretEnv)),
+		Issuer:   os.Getenv(IssuerEnv),
+		Audience: os.Getenv(AudienceEnv),
+	}
+	if path := os.Getenv(JWKSFileEnv); path != "" {
+		keys, err := LoadJWKS(path)
+		if err != nil {
+			return nil, err
+		}
+		v.Keys = keys
+	}
+	if len(v.Secret) == 0 && len(v.Keys) == 0 {
+		return nil, fmt.Errorf("auth: set %s or %s, or %s=true", SecretEnv, JWKSFileEnv, DisabledEnv)
+	}
+	return v, nil
+}
+
+// Verify parses token and checks its signature, expiry and, when
+// configured, its issuer and audience.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(v.methods()),
+		jwt.WithExpirationRequired(),
+	}
+	if v.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.Issuer))
+	}
+	if v.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.Audience))
+	}
+
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(token, claims, v.key, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
+
+func (v *Verifier) methods() []string {
+	var methods []string
+	if len(v.Secret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if len(v.Keys) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	return methods
+}
+
+func (v *Verifier) key(t *jwt.Token) (any, error) {
+	if t.Method == jwt.SigningMethodHS256 {
+		return v.Secret, nil
+	}
+	kid, _ := t.Header["kid"].(string)
+	key, ok := v.Keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("test-secret")
+
+func hsToken(t *testing.T, roles []string, ttl time.Duration) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "ana",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}).SignedString(secret)
+	require.NoError(t, err)
+	return token
+}
+
+func call(handler http.Handler, method, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/products", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func protected(op Operation) (http.Handler, *string) {
+	var subject string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := ClaimsFromContext(r.Context()); ok {
+			subject = c.Subject
+		}
+	})
+	return Require(&Verifier{Secret: secret, APIKeys: fakeKeys{}}, op)(next), &subject
+}
+
+func TestRequire_ReadsArePublic(t *testing.T) {
+	// Arrange
+	handler, _ := protected(OpCreate)
+
+	// Act
+	rec := call(handler, http.MethodGet, "")
+
+	// Assert - Regla de negocio: Las lecturas no requieren autenticación
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequire_MissingOrInvalidToken(t *testing.T) {
+	// Arrange
+	handler, _ := protected(OpCreate)
+
+	// Act
+	missing := call(handler, http.MethodPost, "")
+	expired := call(handler, http.MethodPost, hsToken(t, []string{RoleEditor}, -time.Minute))
+	garbage := call(handler, http.MethodPost, "not-a-jwt")
+
+	// Assert - Regla de negocio: Escribir sin un token válido responde 401
+	for _, rec := range []*httptest.ResponseRecorder{missing, expired, garbage} {
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+	}
+	assert.JSONEq(t, `{"error":"missing bearer token"}`, missing.Body.String())
+	assert.JSONEq(t, `{"error":"invalid token"}`, expired.Body.String())
+}
+
+func TestRequire_Roles(t *testing.T) {
+	// Arrange
+	editorRoute, subject := protected(OpUpdate)
+	adminRoute, _ := protected(OpDelete)
+
+	// Act
+	editorWrites := call(editorRoute, http.MethodPost, hsToken(t, []string{RoleEditor}, time.Minute))
+	viewerWrites := call(editorRoute, http.MethodPost, hsToken(t, nil, time.Minute))
+	editorDeletes := call(adminRoute, http.MethodDelete, hsToken(t, []string{RoleEditor}, time.Minute))
+	adminWrites := call(editorRoute, http.MethodPatch, hsToken(t, []string{RoleAdmin}, time.Minute))
+
+	// Assert - Regla de negocio: Editores escriben, solo administradores eliminan
+	assert.Equal(t, http.StatusOK, editorWrites.Code)
+	assert.Equal(t, "ana", *subject, "Las claims llegan al controlador")
+	assert.Equal(t, http.StatusForbidden, viewerWrites.Code)
+	assert.JSONEq(t, `{"error":"insufficient permissions"}`, viewerWrites.Body.String())
+	assert.Equal(t, http.StatusForbidden, editorDeletes.Code)
+	assert.Equal(t, http.StatusOK, adminWrites.Code, "Administrador también puede editar")
+}
+
+func TestVerifier_RS256WithJWKS(t *testing.T) {
+	// Arrange
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks, 0o600))
+	keys, err := LoadJWKS(path)
+	require.NoError(t, err)
+	v := &Verifier{Keys: keys, Issuer: "https://issuer.test"}
+
+	sign := func(kid, iss string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, Claims{
+			Roles: []string{RoleAdmin},
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    iss,
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+		token.Header["kid"] = kid
+		s, err := token.SignedString(key)
+		require.NoError(t, err)
+		return s
+	}
+
+	// Act
+	claims, err := v.Verify(sign("k1", "https://issuer.test"))
+	_, unknownKid := v.Verify(sign("k2", "https://issuer.test"))
+	_, wrongIssuer := v.Verify(sign("k1", "https://other.test"))
+	_, hsRejected := v.Verify(hsToken(t, []string{RoleAdmin}, time.Minute))
+
+	// Assert - Regla de negocio: Solo se aceptan tokens firmados con claves conocidas
+	require.NoError(t, err)
+	assert.True(t, claims.HasRole(RoleAdmin))
+	assert.ErrorIs(t, unknownKid, ErrInvalidToken)
+	assert.ErrorIs(t, wrongIssuer, ErrInvalidToken)
+	assert.ErrorIs(t, hsRejected, ErrInvalidToken, "Sin secreto configurado HS256 no es válido")
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(SecretEnv, "")
+	t.Setenv(JWKSFileEnv, "")
+	_, err := FromEnv()
+	assert.Error(t, err, "Sin claves la autenticación no puede arrancar")
+
+	t.Setenv(DisabledEnv, "true")
+	v, err := FromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	t.Setenv(DisabledEnv, "")
+	t.Setenv(SecretEnv, "s3cret")
+	v, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("s3cret"), v.Secret)
+}
+
+// fakeKeys accepts "pak_reader" (read only) and "pak_writer" (create and
+// read), and fails with a storage error for "pak_broken".
+type fakeKeys struct{}
+
+func (fakeKeys) VerifyKey(_ context.Context, key string) (*Claims, error) {
+	switch key {
+	case "pak_reader":
+		return &Claims{Scopes: []Operation{OpRead}}, nil
+	case "pak_writer":
+		return &Claims{Scopes: []Operation{OpCreate, OpRead}}, nil
+	case "pak_broken":
+		return nil, errors.New("error de conexión")
+	}
+	return nil, ErrInvalidToken
+}
+
+func TestRequire_APIKeyScopes(t *testing.T) {
+	// Arrange
+	createRoute, _ := protected(OpCreate)
+	withHeader := func(method, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/products", nil)
+		req.Header.Set(APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		createRoute.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Act
+	writerCreates := withHeader(http.MethodPost, "pak_writer")
+	writerAsBearer := call(createRoute, http.MethodPost, "pak_writer")
+	readerCreates := withHeader(http.MethodPost, "pak_reader")
+	readerReads := withHeader(http.MethodGet, "pak_reader")
+	unknownReads := withHeader(http.MethodGet, "pak_unknown")
+	broken := withHeader(http.MethodPost, "pak_broken")
+
+	// Assert - Regla de negocio: Una API key solo permite las operaciones de su alcance
+	assert.Equal(t, http.StatusOK, writerCreates.Code)
+	assert.Equal(t, http.StatusOK, writerAsBearer.Code, "La API key también se acepta en Authorization")
+	assert.Equal(t, http.StatusForbidden, readerCreates.Code)
+	assert.Equal(t, http.StatusOK, readerReads.Code)
+	assert.Equal(t, http.StatusUnauthorized, unknownReads.Code, "Una credencial inválida se rechaza incluso en lecturas")
+	assert.Equal(t, http.StatusInternalServerError, broken.Code)
+}
+
+func TestRequireRole_RefusesAPIKeys(t *testing.T) {
+	// Arrange
+	v := &Verifier{Secret: secret, APIKeys: fakeKeys{}}
+	handler := RequireRole(v, RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Act
+	admin := call(handler, http.MethodGet, hsToken(t, []string{RoleAdmin}, time.Minute))
+	editor := call(handler, http.MethodGet, hsToken(t, []string{RoleEditor}, time.Minute))
+	key := call(handler, http.MethodGet, "pak_writer")
+	anonymous := call(handler, http.MethodGet, "")
+
+	// Assert - Regla de negocio: Solo un administrador autenticado con JWT accede
+	assert.Equal(t, http.StatusOK, admin.Code)
+	assert.Equal(t, http.StatusForbidden, editor.Code)
+	assert.Equal(t, http.StatusForbidden, key.Code)
+	assert.Equal(t, http.StatusUnauthorized, anonymous.Code)
+}
+
+func TestRequireRole_AuthDisabled(t *testing.T) {
+	// Arrange
+	var v *Verifier
+	handler := RequireRole(v, RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Act
+	anonymous := call(handler, http.MethodGet, "")
+	withToken := call(handler, http.MethodPost, hsToken(t, []string{RoleAdmin}, time.Minute))
+
+	// Assert - Regla de negocio: Sin autenticación nadie puede probar el rol de administrador
+	assert.Equal(t, http.StatusForbidden, anonymous.Code)
+	assert.Equal(t, http.StatusForbidden, withToken.Code)
+	assert.Contains(t, anonymous.Body.String(), ErrAuthDisabled.Error())
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/blandoncj/go-products-api/pkg/logging"
+)
+
+// APIKeyHeader carries an API key, as an alternative to
+// "Authorization: Bearer <key>".
+const APIKeyHeader = "X-API-Key"
+
+// Require authorizes op for every request except GET, HEAD and OPTIONS,
+// which are reads: they are public, but credentials sent with them are
+// still checked. Failures are answered with 401 or 403 and a JSON error. A
+// nil Verifier disables the check.
+func Require(v *Verifier, op Operation) func(http.Handler) http.Handler {
+	return v.middleware(func(r *http.Request, c *Claims) bool {
+		if isRead(r) {
+			return c.Allows(OpRead)
+		}
+		return c.Allows(op)
+	}, true)
+}
+
+// ErrAuthDisabled is returned for role-protected routes when no Verifier
+// is configured.
+var ErrAuthDisabled = errors.New("authentication is disabled")
+
+// RequireRole lets through only JWTs granting role, for every method. API
+// keys are refused. Unlike Require, a nil Verifier refuses every request
+// with 403, since no caller can prove the role.
+func RequireRole(v *Verifier, role string) func(http.Handler) http.Handler {
+	if v == nil {
+		return func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeError(w, http.StatusForbidden, ErrAuthDisabled)
+			})
+		}
+	}
+	return v.middleware(func(r *http.Request, c *Claims) bool {
+		return !c.IsAPIKey() && c.HasRole(role)
+	}, false)
+}
+
+func isRead(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func (v *Verifier) middleware(allowed func(*http.Request, *Claims) bool, publicReads bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if v == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if publicReads && isRead(r) && !hasCredentials(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			claims, err := v.Authenticate(r)
+			if err != nil && !errors.Is(err, ErrMissingToken) && !errors.Is(err, ErrInvalidToken) {
+				logging.FromContext(r.Context()).Error("authentication unavailable", "error", err)
+				writeError(w, http.StatusInternalServerError, errors.New("authentication unavailable"))
+				return
+			}
+			if err != nil {
+				logging.FromContext(r.Context()).Info("authentication failed", "error", err)
+				challenge := "Bearer"
+				if !errors.Is(err, ErrMissingToken) {
+					challenge += ` error="invalid_token"`
+				}
+				w.Header().Set("WWW-Authenticate", challenge)
+				writeError(w, http.StatusUnauthorized, err)
+				return
+			}
+			if !allowed(r, claims) {
+				writeError(w, http.StatusForbidden, ErrForbidden)
+				return
+			}
+			ctx := WithClaims(r.Context(), claims)
+			ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("subject", claims.Subject))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Authenticate verifies the API key in X-API-Key or the bearer token in
+// the Authorization header, which may itself be an API key.
+func (v *Verifier) Authenticate(r *http.Request) (*Claims, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return v.verifyKey(r, key)
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, ErrMissingToken
+	}
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		return v.verifyKey(r, token)
+	}
+	return v.Verify(token)
+}
+
+func (v *Verifier) verifyKey(r *http.Request, key string) (*Claims, error) {
+	if v.APIKeys == nil {
+		return nil, fmt.Errorf("%w: api keys are not accepted", ErrInvalidToken)
+	}
+	return v.APIKeys.VerifyKey(r.Context(), key)
+}
+
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get(APIKeyHeader) != ""
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	msg := err.Error()
+	if errors.Is(err, ErrInvalidToken) {
+		// The parser's reason is useful in logs, not to callers.
+		msg = ErrInvalidToken.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
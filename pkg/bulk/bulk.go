@@ -0,0 +1,127 @@
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/blandoncj/go-products-api/pkg/validation"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MaxItems caps the number of items accepted in one bulk request.
+const MaxItems = 10000
+
+const (
+	StatusPending   = ""
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+)
+
+var ErrTooManyItems = fmt.Errorf("a bulk request accepts at most %d items", MaxItems)
+
+type ItemResult struct {
+	Index  int               `json:"index"`
+	ID     string            `json:"id,omitempty"`
+	Status string            `json:"status"`
+	Error  string            `json:"error,omitempty"`
+	Fields validation.Errors `json:"fields,omitempty"`
+}
+
+// Result reports the outcome of every item of a bulk request, in request
+// order. In ordered mode the items after the first failure are skipped.
+type Result struct {
+	Ordered   bool         `json:"ordered"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Skipped   int          `json:"skipped"`
+	Items     []ItemResult `json:"items"`
+}
+
+func NewResult(n int, ordered bool) *Result {
+	items := make([]ItemResult, n)
+	for i := range items {
+		items[i].Index = i
+	}
+	return &Result{Ordered: ordered, Items: items}
+}
+
+func (r *Result) SetID(i int, id string) {
+	r.Items[i].ID = id
+}
+
+func (r *Result) Succeed(i int) {
+	r.Items[i].Status = StatusSucceeded
+}
+
+func (r *Result) Fail(i int, err error) {
+	r.Items[i].Status = StatusFailed
+	r.Items[i].Error = err.Error()
+	var verrs validation.Errors
+	if errors.As(err, &verrs) {
+		r.Items[i].Error = "validation failed"
+		r.Items[i].Fields = verrs
+	}
+}
+
+// Finish marks every item that was never attempted as skipped and fills
+// in the totals.
+func (r *Result) Finish() *Result {
+	r.Succeeded, r.Failed, r.Skipped = 0, 0, 0
+	for i := range r.Items {
+		switch r.Items[i].Status {
+		case StatusSucceeded:
+			r.Succeeded++
+		case StatusFailed:
+			r.Failed++
+		default:
+			r.Items[i].Status = StatusSkipped
+			r.Skipped++
+		}
+	}
+	return r
+}
+
+func (r *Result) HasFailures() bool {
+	return r.Failed > 0 || r.Skipped > 0
+}
+
+// WriteErrors extracts the per-operation errors of a bulk write, keyed by
+// the index of the operation in the batch sent to MongoDB. ok is false
+// when err is not a bulk write error, i.e. the whole batch failed.
+func WriteErrors(err error) (errs map[int]error, ok bool) {
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || bwe.WriteConcernError != nil {
+		return nil, false
+	}
+	errs = make(map[int]error, len(bwe.WriteErrors))
+	for _, we := range bwe.WriteErrors {
+		errs[we.Index] = errors.New(we.Message)
+	}
+	return errs, true
+}
+
+// WriteError extracts the error of a single write that MongoDB rejected,
+// such as a document failing validation. ok is false when err is not a
+// write error, i.e. the write could not be attempted.
+func WriteError(err error) (_ error, ok bool) {
+	var we mongo.WriteException
+	if !errors.As(err, &we) || we.WriteConcernError != nil || len(we.WriteErrors) == 0 {
+		return nil, false
+	}
+	return errors.New(we.WriteErrors[0].Message), true
+}
+
+// ParseOrdered reads the ordered query parameter. Like MongoDB, bulk
+// requests are ordered unless the caller opts out.
+func ParseOrdered(v string) (bool, error) {
+	if v == "" {
+		return true, nil
+	}
+	ordered, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid ordered: %q", v)
+	}
+	return ordered, nil
+}
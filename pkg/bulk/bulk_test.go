@@ -0,0 +1,73 @@
+package bulk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/blandoncj/go-products-api/pkg/validation"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestResult_Finish(t *testing.T) {
+	// Arrange
+	res := NewResult(4, true)
+	res.Succeed(0)
+	res.Fail(1, validation.Errors{{Field: "price", Rule: "min", Message: "must be greater than or equal to 0"}})
+
+	// Act
+	res.Finish()
+
+	// Assert - Regla de negocio: En modo ordenado lo que sigue al primer fallo queda omitido
+	assert.Equal(t, 1, res.Succeeded)
+	assert.Equal(t, 1, res.Failed)
+	assert.Equal(t, 2, res.Skipped)
+	assert.Equal(t, "validation failed", res.Items[1].Error)
+	assert.Len(t, res.Items[1].Fields, 1)
+	assert.Equal(t, StatusSkipped, res.Items[3].Status)
+	assert.True(t, res.HasFailures())
+}
+
+func TestWriteErrors(t *testing.T) {
+	// Arrange
+	err := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 2, Code: 11000, Message: "duplicate key"}},
+	}}
+
+	// Act
+	errs, ok := WriteErrors(err)
+	_, networkOK := WriteErrors(errors.New("connection reset"))
+
+	// Assert - Regla de negocio: Los errores de escritura se asignan a su operación
+	assert.True(t, ok)
+	assert.EqualError(t, errs[2], "duplicate key")
+	assert.False(t, networkOK, "Un error de red no es un error por operación")
+}
+
+func TestWriteError(t *testing.T) {
+	// Arrange
+	err := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 121, Message: "document failed validation"}}}
+
+	// Act
+	single, ok := WriteError(err)
+	_, networkOK := WriteError(errors.New("connection reset"))
+
+	// Assert - Regla de negocio: Un documento rechazado se distingue de un fallo de conexión
+	assert.True(t, ok)
+	assert.EqualError(t, single, "document failed validation")
+	assert.False(t, networkOK)
+}
+
+func TestParseOrdered(t *testing.T) {
+	// Act
+	byDefault, defaultErr := ParseOrdered("")
+	unordered, unorderedErr := ParseOrdered("false")
+	_, invalidErr := ParseOrdered("maybe")
+
+	// Assert - Regla de negocio: Por defecto es ordenado, como MongoDB
+	assert.NoError(t, defaultErr)
+	assert.True(t, byDefault)
+	assert.NoError(t, unorderedErr)
+	assert.False(t, unordered)
+	assert.Error(t, invalidErr)
+}
@@ -0,0 +1,265 @@
+package database
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+// ConfigFileEnv names an optional file of KEY=value lines read by Load.
+// Variables set in the environment take precedence over the file.
+const ConfigFileEnv = "MONGO_CONFIG_FILE"
+
+// Config describes how to reach MongoDB. Either URI or Host must be set;
+// credentials, replica set and TLS settings apply on top of either.
+type Config struct {
+	URI        string
+	Host       string
+	Port       string
+	Username   string
+	Password   string
+	AuthSource string
+	Database   string
+	ReplicaSet string
+
+	TLS         bool
+	TLSCAFile   string
+	TLSCertFile string // PEM file holding the client certificate and key
+	TLSInsecure bool
+
+	MaxPoolSize uint64
+	MinPoolSize uint64
+
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+}
+
+// Load reads the configuration from the environment and the optional
+// MONGO_CONFIG_FILE, and validates it.
+func Load() (Config, error) {
+	return LoadFrom(os.LookupEnv)
+}
+
+// LoadFrom is Load with a custom variable lookup.
+func LoadFrom(lookup func(string) (string, bool)) (Config, error) {
+	l := loader{lookup: lookup}
+	if path, ok := lookup(ConfigFileEnv); ok && path != "" {
+		file, err := readEnvFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		l.file = file
+	}
+
+	cfg := Config{
+		URI:                    l.string("MONGO_URI", ""),
+		Host:                   l.string("MONGO_HOST", ""),
+		Port:                   l.string("MONGO_PORT", "27017"),
+		Username:               l.string("MONGO_ROOT_USERNAME", ""),
+		Password:               l.string("MONGO_ROOT_PASSWORD", ""),
+		AuthSource:             l.string("MONGO_AUTH_SOURCE", "admin"),
+		Database:               l.string("MONGO_DB", ""),
+		ReplicaSet:             l.string("MONGO_REPLICA_SET", ""),
+		TLS:                    l.bool("MONGO_TLS"),
+		TLSCAFile:              l.string("MONGO_TLS_CA_FILE", ""),
+		TLSCertFile:            l.string("MONGO_TLS_CERT_KEY_FILE", ""),
+		TLSInsecure:            l.bool("MONGO_TLS_INSECURE"),
+		MaxPoolSize:            l.uint("MONGO_MAX_POOL_SIZE"),
+		MinPoolSize:            l.uint("MONGO_MIN_POOL_SIZE"),
+		ConnectTimeout:         l.duration("MONGO_CONNECT_TIMEOUT", 10*time.Second),
+		ServerSelectionTimeout: l.duration("MONGO_SERVER_SELECTION_TIMEOUT", 5*time.Second),
+	}
+	if err := errors.Join(l.errs...); err != nil {
+		return cfg, err
+	}
+
+	// a database in the URI path is used when MONGO_DB is not set
+	if cfg.Database == "" && cfg.URI != "" {
+		if cs, err := connstring.Parse(cfg.URI); err == nil {
+			cfg.Database = cs.Database
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate reports every problem with the configuration at once.
+func (c Config) Validate() error {
+	var errs []error
+	if c.URI != "" {
+		if _, err := connstring.ParseAndValidate(c.URI); err != nil {
+			errs = append(errs, fmt.Errorf("invalid MONGO_URI: %w", err))
+		}
+	} else if c.Host == "" {
+		errs = append(errs, errors.New("MONGO_URI or MONGO_HOST is required"))
+	}
+	if (c.Username == "") != (c.Password == "") {
+		errs = append(errs, errors.New("MONGO_ROOT_USERNAME and MONGO_ROOT_PASSWORD must be set together"))
+	}
+	if c.Database == "" {
+		errs = append(errs, errors.New("MONGO_DB is required"))
+	}
+	if c.MaxPoolSize > 0 && c.MinPoolSize > c.MaxPoolSize {
+		errs = append(errs, errors.New("MONGO_MIN_POOL_SIZE is greater than MONGO_MAX_POOL_SIZE"))
+	}
+	if !c.TLS && (c.TLSCAFile != "" || c.TLSCertFile != "" || c.TLSInsecure) {
+		errs = append(errs, errors.New("MONGO_TLS_* settings require MONGO_TLS=true"))
+	}
+	if c.ConnectTimeout <= 0 {
+		errs = append(errs, errors.New("MONGO_CONNECT_TIMEOUT must be positive"))
+	}
+	if c.ServerSelectionTimeout <= 0 {
+		errs = append(errs, errors.New("MONGO_SERVER_SELECTION_TIMEOUT must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+// ClientOptions turns the configuration into driver options. Credentials
+// are passed separately from the URI so they never need escaping.
+func (c Config) ClientOptions() (*options.ClientOptions, error) {
+	uri := c.URI
+	if uri == "" {
+		uri = "mongodb://" + net.JoinHostPort(c.Host, c.Port) + "/"
+	}
+	opts := options.Client().
+		ApplyURI(uri).
+		SetConnectTimeout(c.ConnectTimeout).
+		SetServerSelectionTimeout(c.ServerSelectionTimeout)
+
+	if c.Username != "" {
+		opts.SetAuth(options.Credential{
+			Username:   c.Username,
+			Password:   c.Password,
+			AuthSource: c.AuthSource,
+		})
+	}
+	if c.ReplicaSet != "" {
+		opts.SetReplicaSet(c.ReplicaSet)
+	}
+	if c.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(c.MaxPoolSize)
+	}
+	if c.MinPoolSize > 0 {
+		opts.SetMinPoolSize(c.MinPoolSize)
+	}
+	if c.TLS {
+		tlsConfig, err := c.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+	return opts, nil
+}
+
+func (c Config) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: c.TLSInsecure} // #nosec G402 -- opt-in through MONGO_TLS_INSECURE
+	if c.TLSCAFile != "" {
+		pem, err := os.ReadFile(c.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read MONGO_TLS_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.TLSCAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if c.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("read MONGO_TLS_CERT_KEY_FILE: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+type loader struct {
+	lookup func(string) (string, bool)
+	file   map[string]string
+	errs   []error
+}
+
+func (l *loader) string(key, def string) string {
+	if v, ok := l.lookup(key); ok && v != "" {
+		return v
+	}
+	if v, ok := l.file[key]; ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func (l *loader) bool(key string) bool {
+	v := l.string(key, "")
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s: %q", key, v))
+	}
+	return b
+}
+
+func (l *loader) uint(key string) uint64 {
+	v := l.string(key, "")
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s: %q", key, v))
+	}
+	return n
+}
+
+func (l *loader) duration(key string, def time.Duration) time.Duration {
+	v := l.string(key, "")
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid %s: %q", key, v))
+	}
+	return d
+}
+
+// readEnvFile parses KEY=value lines, skipping blanks and # comments.
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", ConfigFileEnv, err)
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s line %d: expected KEY=value", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func TestLoadFrom_HostDefaults(t *testing.T) {
+	// Arrange
+	vars := env(map[string]string{
+		"MONGO_HOST":          "mongo",
+		"MONGO_ROOT_USERNAME": "admin",
+		"MONGO_ROOT_PASSWORD": "p@ss:word",
+		"MONGO_DB":            "products",
+	})
+
+	// Act
+	cfg, err := LoadFrom(vars)
+	require.NoError(t, err)
+	opts, optsErr := cfg.ClientOptions()
+
+	// Assert - Regla de negocio: Los valores por defecto se aplican y las credenciales no se embeben en la URI
+	require.NoError(t, optsErr)
+	assert.Equal(t, "27017", cfg.Port)
+	assert.Equal(t, "admin", cfg.AuthSource)
+	assert.Equal(t, 10*time.Second, cfg.ConnectTimeout)
+	assert.Equal(t, 5*time.Second, cfg.ServerSelectionTimeout)
+	assert.Equal(t, []string{"mongo:27017"}, opts.Hosts)
+	assert.Equal(t, "p@ss:word", opts.Auth.Password)
+}
+
+func TestLoadFrom_URI(t *testing.T) {
+	// Arrange
+	vars := env(map[string]string{
+		"MONGO_URI":           "mongodb://a:27017,b:27017/catalog",
+		"MONGO_REPLICA_SET":   "rs0",
+		"MONGO_MAX_POOL_SIZE": "50",
+		"MONGO_MIN_POOL_SIZE": "5",
+	})
+
+	// Act
+	cfg, err := LoadFrom(vars)
+	require.NoError(t, err)
+	opts, optsErr := cfg.ClientOptions()
+
+	// Assert - Regla de negocio: La base de datos puede venir de la URI
+	require.NoError(t, optsErr)
+	assert.Equal(t, "catalog", cfg.Database)
+	assert.Equal(t, []string{"a:27017", "b:27017"}, opts.Hosts)
+	assert.Equal(t, "rs0", *opts.ReplicaSet)
+	assert.Equal(t, uint64(50), *opts.MaxPoolSize)
+	assert.Nil(t, opts.Auth)
+}
+
+func TestLoadFrom_ReportsAllErrors(t *testing.T) {
+	// Arrange
+	vars := env(map[string]string{
+		"MONGO_ROOT_USERNAME": "admin",
+		"MONGO_TLS_CA_FILE":   "/etc/ca.pem",
+		"MONGO_MAX_POOL_SIZE": "10",
+		"MONGO_MIN_POOL_SIZE": "20",
+	})
+
+	// Act
+	_, err := LoadFrom(vars)
+
+	// Assert - Regla de negocio: Se informan todos los errores de configuración a la vez
+	require.Error(t, err)
+	for _, msg := range []string{"MONGO_HOST", "set together", "MONGO_DB", "MONGO_MIN_POOL_SIZE", "MONGO_TLS=true"} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
+
+func TestLoadFrom_InvalidValues(t *testing.T) {
+	// Arrange
+	vars := env(map[string]string{
+		"MONGO_HOST":            "mongo",
+		"MONGO_DB":              "products",
+		"MONGO_TLS":             "maybe",
+		"MONGO_CONNECT_TIMEOUT": "10",
+	})
+
+	// Act
+	_, err := LoadFrom(vars)
+
+	// Assert - Regla de negocio: Los valores mal formados se rechazan indicando la variable
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid MONGO_TLS: "maybe"`)
+	assert.Contains(t, err.Error(), `invalid MONGO_CONNECT_TIMEOUT: "10"`)
+}
+
+func TestLoadFrom_ConfigFile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "mongo.env")
+	content := "# shared settings\nMONGO_HOST=from-file\nMONGO_DB=\"products\"\nMONGO_PORT=27018\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	vars := env(map[string]string{
+		ConfigFileEnv: path,
+		"MONGO_HOST":  "from-env",
+	})
+
+	// Act
+	cfg, err := LoadFrom(vars)
+
+	// Assert - Regla de negocio: El entorno tiene prioridad sobre el archivo
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.Host)
+	assert.Equal(t, "products", cfg.Database)
+	assert.Equal(t, "27018", cfg.Port)
+}
+
+func TestLoadFrom_MissingConfigFile(t *testing.T) {
+	// Arrange
+	vars := env(map[string]string{ConfigFileEnv: "/does/not/exist"})
+
+	// Act
+	_, err := LoadFrom(vars)
+
+	// Assert - Regla de negocio: Un archivo de configuración inexistente es un error
+	assert.ErrorContains(t, err, ConfigFileEnv)
+}
+
+func TestClientOptions_TLS(t *testing.T) {
+	// Arrange
+	cfg := Config{Host: "mongo", Port: "27017", Database: "products", TLS: true, TLSInsecure: true,
+		ConnectTimeout: time.Second, ServerSelectionTimeout: time.Second}
+	missingCA := cfg
+	missingCA.TLSCAFile = "/does/not/exist"
+
+	// Act
+	opts, err := cfg.ClientOptions()
+	_, caErr := missingCA.ClientOptions()
+
+	// Assert - Regla de negocio: TLS respeta la verificación configurada y exige un CA legible
+	require.NoError(t, err)
+	assert.True(t, opts.TLSConfig.InsecureSkipVerify)
+	assert.ErrorContains(t, caErr, "MONGO_TLS_CA_FILE")
+}
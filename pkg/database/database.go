@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blandoncj/go-products-api/pkg/health"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Option adjusts the client options built from the configuration, for
+// example to attach monitors.
+type Option func(*options.ClientOptions)
+
+// Connect opens a client and pings the primary, so a bad configuration
+// fails at startup instead of on the first request.
+func Connect(ctx context.Context, cfg Config, opts ...Option) (*mongo.Client, error) {
+	clientOpts, err := cfg.ClientOptions()
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(clientOpts)
+	}
+	client, err := mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("connect to mongodb: %w", err)
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.ServerSelectionTimeout)
+	defer cancel()
+	if err := client.Ping(pingCtx, readpref.Primary()); err != nil {
+		_ = client.Disconnect(ctx)
+		return nil, fmt.Errorf("ping mongodb: %w", err)
+	}
+	return client, nil
+}
+
+// Open loads the configuration from the environment and returns the
+// configured database.
+func Open(ctx context.Context, opts ...Option) (*mongo.Database, error) {
+	cfg, err := Load()
+	if err != nil {
+		return nil, fmt.Errorf("mongodb config: %w", err)
+	}
+	client, err := Connect(ctx, cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return client.Database(cfg.Database), nil
+}
+
+// HealthCheck pings the primary of the deployment behind db.
+func HealthCheck(db *mongo.Database) health.Check {
+	return health.Check{
+		Name: "mongodb",
+		Check: func(ctx context.Context) error {
+			return db.Client().Ping(ctx, readpref.Primary())
+		},
+	}
+}
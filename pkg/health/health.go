@@ -0,0 +1,152 @@
+// Package health serves the liveness and readiness probes shared by the
+// services.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	DefaultTimeout = 2 * time.Second
+)
+
+// Version is the build version reported by the probes. Release builds set
+// it with -ldflags "-X github.com/blandoncj/go-products-api/pkg/health.Version=...".
+var Version = "dev"
+
+// Check reports whether a dependency can serve requests.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status       string                      `json:"status"`
+	Service      string                      `json:"service"`
+	Version      string                      `json:"version"`
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"`
+}
+
+// Checker answers /livez and /readyz for one service.
+type Checker struct {
+	Service string
+	Checks  []Check
+	// Timeout bounds every readiness check. DefaultTimeout when zero.
+	Timeout time.Duration
+}
+
+// NewChecker returns a checker whose timeout comes from READINESS_TIMEOUT
+// when it is set to a valid duration.
+func NewChecker(service string, checks ...Check) *Checker {
+	c := &Checker{Service: service, Checks: checks, Timeout: DefaultTimeout}
+	if v := os.Getenv("READINESS_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			c.Timeout = d
+		}
+	}
+	return c
+}
+
+// Register adds the /livez and /readyz routes to mux.
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/livez", c.Livez)
+	mux.HandleFunc("/readyz", c.Readyz)
+}
+
+// Livez reports that the process is running. It never touches a
+// dependency, so a database outage does not get the service restarted.
+func (c *Checker) Livez(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, Report{Status: StatusUp, Service: c.Service, Version: Version})
+}
+
+// Readyz runs every check concurrently and answers 503 if any fails.
+func (c *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, c.Ready(r.Context()))
+}
+
+// Ready runs the readiness checks.
+func (c *Checker) Ready(ctx context.Context) Report {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report := Report{
+		Status:       StatusUp,
+		Service:      c.Service,
+		Version:      Version,
+		Dependencies: make(map[string]DependencyStatus, len(c.Checks)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.Checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Check(ctx)
+			dep := DependencyStatus{Status: StatusUp, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				dep.Status = StatusDown
+				dep.Error = err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[check.Name] = dep
+			if err != nil {
+				report.Status = StatusDown
+			}
+		}(check)
+	}
+	wg.Wait()
+	return report
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	status := http.StatusOK
+	if report.Status != StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+var probeClient = &http.Client{Timeout: 5 * time.Second}
+
+// Probe asks a running service for its readiness. The binaries use it to
+// implement their healthcheck subcommand, since the images have no shell
+// or curl.
+func Probe(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("readiness probe returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func up(context.Context) error { return nil }
+
+func serve(handler http.HandlerFunc) (*httptest.ResponseRecorder, Report) {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var report Report
+	_ = json.Unmarshal(rec.Body.Bytes(), &report)
+	return rec, report
+}
+
+func TestLivez_IgnoresDependencies(t *testing.T) {
+	// Arrange
+	checker := &Checker{Service: "create", Checks: []Check{{Name: "mongodb", Check: func(context.Context) error {
+		return errors.New("unreachable")
+	}}}}
+
+	// Act
+	rec, report := serve(checker.Livez)
+
+	// Assert - Regla de negocio: La vida del proceso no depende de sus dependencias
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, Version, report.Version)
+	assert.Empty(t, report.Dependencies)
+}
+
+func TestReadyz_AllUp(t *testing.T) {
+	// Arrange
+	checker := &Checker{Service: "read", Checks: []Check{{Name: "mongodb", Check: up}}}
+
+	// Act
+	rec, report := serve(checker.Readyz)
+
+	// Assert - Regla de negocio: El servicio está listo cuando sus dependencias responden
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "read", report.Service)
+	require.Contains(t, report.Dependencies, "mongodb")
+	assert.Equal(t, StatusUp, report.Dependencies["mongodb"].Status)
+}
+
+func TestReadyz_DependencyDown(t *testing.T) {
+	// Arrange
+	checker := &Checker{Service: "read", Checks: []Check{
+		{Name: "mongodb", Check: func(context.Context) error { return errors.New("connection refused") }},
+		{Name: "cache", Check: up},
+	}}
+
+	// Act
+	rec, report := serve(checker.Readyz)
+
+	// Assert - Regla de negocio: Una dependencia caída deja el servicio no listo y se informa su error
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "connection refused", report.Dependencies["mongodb"].Error)
+	assert.Equal(t, StatusUp, report.Dependencies["cache"].Status)
+}
+
+func TestReadyz_Timeout(t *testing.T) {
+	// Arrange
+	checker := &Checker{Service: "read", Timeout: 20 * time.Millisecond, Checks: []Check{{Name: "mongodb", Check: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}}}
+
+	// Act
+	start := time.Now()
+	rec, report := serve(checker.Readyz)
+
+	// Assert - Regla de negocio: Una dependencia lenta no bloquea la comprobación
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Greater(t, report.Dependencies["mongodb"].LatencyMS, 0.0)
+}
+
+func TestProbe(t *testing.T) {
+	// Arrange
+	checker := &Checker{Service: "read", Checks: []Check{{Name: "mongodb", Check: up}}}
+	mux := http.NewServeMux()
+	checker.Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Act
+	readyErr := Probe(context.Background(), srv.URL+"/readyz")
+	missingErr := Probe(context.Background(), srv.URL+"/missing")
+
+	// Assert - Regla de negocio: La sonda falla si el endpoint no responde 200
+	assert.NoError(t, readyErr)
+	assert.Error(t, missingErr)
+}
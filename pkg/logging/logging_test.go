@@ -0,0 +1,125 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func serve(t *testing.T, header string, handler http.HandlerFunc) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	logger := NewWithWriter(&buf, "test-service", "info")
+	req := httptest.NewRequest(http.MethodGet, "/products", nil)
+	if header != "" {
+		req.Header.Set(RequestIDHeader, header)
+	}
+	rec := httptest.NewRecorder()
+	Middleware(logger)(handler).ServeHTTP(rec, req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var last map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &last))
+	return rec, last
+}
+
+func TestMiddleware_LogsOneLinePerRequest(t *testing.T) {
+	// Act
+	rec, line := serve(t, "", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	// Assert - Regla de negocio: Cada petición deja una línea con su ID, generado si el cliente no envía uno
+	id := rec.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 32)
+	assert.Equal(t, "request", line["msg"])
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, "test-service", line["service"])
+	assert.Equal(t, id, line["request_id"])
+	assert.Equal(t, http.MethodGet, line["method"])
+	assert.Equal(t, "/products", line["path"])
+	assert.Equal(t, float64(http.StatusTeapot), line["status"])
+	assert.Equal(t, float64(5), line["bytes"])
+	assert.Contains(t, line, "duration_ms")
+}
+
+func TestMiddleware_PropagatesRequestID(t *testing.T) {
+	// Arrange
+	var seen, fromContext string
+
+	// Act
+	rec, line := serve(t, "abc-123", func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(RequestIDHeader)
+		fromContext = RequestID(r.Context())
+		FromContext(r.Context()).Info("inside handler")
+	})
+
+	// Assert - Regla de negocio: El ID del cliente llega a los servicios de destino y a la respuesta
+	assert.Equal(t, "abc-123", rec.Header().Get(RequestIDHeader))
+	assert.Equal(t, "abc-123", seen)
+	assert.Equal(t, "abc-123", fromContext)
+	assert.Equal(t, "abc-123", line["request_id"])
+}
+
+func TestMiddleware_RejectsInvalidRequestID(t *testing.T) {
+	// Act
+	rec, _ := serve(t, "bad id\n", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Assert - Regla de negocio: Un ID con caracteres no imprimibles se reemplaza
+	assert.NotEqual(t, "bad id\n", rec.Header().Get(RequestIDHeader))
+	assert.Len(t, rec.Header().Get(RequestIDHeader), 32)
+}
+
+func TestMiddleware_ServerErrorsLogAtErrorLevel(t *testing.T) {
+	// Act
+	_, line := serve(t, "", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	// Assert - Regla de negocio: Los errores del servidor se registran con nivel ERROR
+	assert.Equal(t, "ERROR", line["level"])
+}
+
+func TestFromContext_ScopedLogger(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := NewWithWriter(&buf, "test-service", "debug").With("request_id", "r1")
+	ctx := WithLogger(context.Background(), logger)
+
+	// Act
+	FromContext(ctx).Debug("repository call", "operation", "FindByID")
+	outside := FromContext(context.Background())
+
+	// Assert - Regla de negocio: El logger de la petición conserva sus atributos y fuera de ella se usa el de por defecto
+	assert.Contains(t, buf.String(), `"request_id":"r1"`)
+	assert.Contains(t, buf.String(), `"operation":"FindByID"`)
+	assert.NotNil(t, outside)
+}
+
+func TestMiddleware_TagsTraceID(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := NewWithWriter(&buf, "test-service", "info")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9},
+		SpanID:     trace.SpanID{0x01},
+		TraceFlags: trace.FlagsSampled,
+	})
+	req := httptest.NewRequest(http.MethodGet, "/products", nil)
+	req = req.WithContext(trace.ContextWithSpanContext(req.Context(), sc))
+
+	// Act
+	Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert - Regla de negocio: Las líneas de una petición trazada llevan su trace_id
+	assert.Contains(t, buf.String(), `"trace_id":"`+sc.TraceID().String()+`"`)
+}
@@ -0,0 +1,89 @@
+package memstore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// File is a BSON document on disk that every process opening the same
+// path shares, with one field per collection. BSON keeps the same fields
+// MongoDB would, including those hidden from JSON such as API key hashes.
+type File struct {
+	path string
+	// the file lock is held per process, so goroutines queue here first
+	mu sync.Mutex
+}
+
+// OpenFile opens the shared file at path, creating it empty if needed.
+func OpenFile(path string) (*File, error) {
+	fd, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := fd.Close(); err != nil {
+		return nil, err
+	}
+	return &File{path: path}, nil
+}
+
+// Sync loads collection into v, which must be a pointer, runs fn and
+// writes v back when fn returns true. The file stays locked for the whole
+// call, so a read-modify-write never interleaves with another process's.
+func (f *File) Sync(collection string, v any, fn func() bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fd, err := os.OpenFile(f.path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	if err := lockFile(fd); err != nil {
+		return fmt.Errorf("lock %s: %w", f.path, err)
+	}
+	defer unlockFile(fd)
+
+	raw, err := io.ReadAll(fd)
+	if err != nil {
+		return err
+	}
+	doc := bson.D{}
+	if len(raw) > 0 {
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return fmt.Errorf("parse %s: %w", f.path, err)
+		}
+	}
+	reflect.ValueOf(v).Elem().SetZero()
+	if value, err := bson.Raw(raw).LookupErr(collection); err == nil {
+		if err := value.Unmarshal(v); err != nil {
+			return fmt.Errorf("parse %s in %s: %w", collection, f.path, err)
+		}
+	}
+
+	if !fn() {
+		return nil
+	}
+	doc = setField(doc, collection, v)
+	if raw, err = bson.Marshal(doc); err != nil {
+		return err
+	}
+	if err := fd.Truncate(0); err != nil {
+		return err
+	}
+	_, err = fd.WriteAt(raw, 0)
+	return err
+}
+
+func setField(doc bson.D, key string, value any) bson.D {
+	for i := range doc {
+		if doc[i].Key == key {
+			doc[i].Value = value
+			return doc
+		}
+	}
+	return append(doc, bson.E{Key: key, Value: value})
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package memstore
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoFileLock = errors.New("shared memory store files need a Unix system")
+
+func lockFile(*os.File) error { return errNoFileLock }
+
+func unlockFile(*os.File) error { return nil }
@@ -0,0 +1,16 @@
+//go:build unix
+
+package memstore
+
+import (
+	"os"
+	"syscall"
+)
+
+func lockFile(fd *os.File) error {
+	return syscall.Flock(int(fd.Fd()), syscall.LOCK_EX)
+}
+
+func unlockFile(fd *os.File) error {
+	return syscall.Flock(int(fd.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,286 @@
+// Package memstore keeps products in memory for running the services
+// without MongoDB. Each process has its own store unless MEMORY_STORE_FILE
+// names a file that the services share.
+package memstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// BackendEnv selects the repository implementation: "mongo" (the
+	// default) or "memory".
+	BackendEnv = "STORAGE_BACKEND"
+	// SeedFileEnv optionally names a JSON array of products loaded into
+	// the memory store at startup.
+	SeedFileEnv = "MEMORY_SEED_FILE"
+	// FileEnv optionally names a file shared by every service process, so
+	// they all see the same products, API keys and audit entries. The data
+	// also survives restarts.
+	FileEnv = "MEMORY_STORE_FILE"
+)
+
+const productsField = "products"
+
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// Enabled reports whether STORAGE_BACKEND selects the memory store.
+func Enabled() (bool, error) {
+	switch v := os.Getenv(BackendEnv); v {
+	case "", "mongo":
+		return false, nil
+	case "memory":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid %s: %q", BackendEnv, v)
+	}
+}
+
+// FromEnv returns a new store, kept in MEMORY_STORE_FILE when set and
+// seeded from MEMORY_SEED_FILE when set. A shared file is only seeded
+// while it holds no products, so every service can be given the seed.
+func FromEnv() (*Store, error) {
+	store := New()
+	if path := os.Getenv(FileEnv); path != "" {
+		file, err := OpenFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", FileEnv, err)
+		}
+		store = NewShared(file)
+	}
+	path := os.Getenv(SeedFileEnv)
+	if path == "" {
+		return store, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", SeedFileEnv, err)
+	}
+	var products []model.Product
+	if err := json.Unmarshal(raw, &products); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", SeedFileEnv, err)
+	}
+	for i, p := range products {
+		if p.ID.IsZero() {
+			products[i].ID = primitive.NewObjectID()
+		}
+		if p.Version == 0 {
+			products[i].Version = model.InitialVersion
+		}
+	}
+	if err := store.seed(products); err != nil {
+		return nil, fmt.Errorf("seed %s: %w", SeedFileEnv, err)
+	}
+	return store, nil
+}
+
+// Store is a map of products guarded by a mutex. Products go in and out
+// by value, so callers never share memory with the store. A shared store
+// reloads the map from its File on every call and saves it after writes.
+type Store struct {
+	mu       sync.RWMutex
+	products map[primitive.ObjectID]model.Product
+	file     *File
+}
+
+func New() *Store {
+	return &Store{products: map[primitive.ObjectID]model.Product{}}
+}
+
+// NewShared returns a store kept in file.
+func NewShared(file *File) *Store {
+	return &Store{products: map[primitive.ObjectID]model.Product{}, file: file}
+}
+
+// File returns the file the store is kept in, or nil.
+func (s *Store) File() *File {
+	return s.file
+}
+
+// read runs fn on the current products.
+func (s *Store) read(fn func()) {
+	if s.file == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		fn()
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logError(s.sync(func() bool {
+		fn()
+		return false
+	}))
+}
+
+// write runs fn on the current products and keeps its changes when it
+// returns true.
+func (s *Store) write(fn func() bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		fn()
+		return nil
+	}
+	return s.sync(fn)
+}
+
+func (s *Store) sync(fn func() bool) error {
+	var products []model.Product
+	return s.file.Sync(productsField, &products, func() bool {
+		s.products = make(map[primitive.ObjectID]model.Product, len(products))
+		for _, p := range products {
+			s.products[p.ID] = p
+		}
+		if !fn() {
+			return false
+		}
+		products = s.sorted()
+		return true
+	})
+}
+
+// logError reports a failure of the shared file from methods that cannot
+// return one.
+func (s *Store) logError(err error) {
+	if err != nil {
+		slog.Error("memory store file failed", "error", err)
+	}
+}
+
+func (s *Store) seed(products []model.Product) error {
+	var err error
+	writeErr := s.write(func() bool {
+		if s.file != nil && len(s.products) > 0 {
+			return false
+		}
+		for _, p := range products {
+			if _, ok := s.products[p.ID]; ok {
+				err = fmt.Errorf("product %s: %w", p.ID.Hex(), ErrDuplicateKey)
+				return false
+			}
+			s.products[p.ID] = clone(p)
+		}
+		return true
+	})
+	return errors.Join(err, writeErr)
+}
+
+// Insert adds a product, failing like a unique index if the ID is taken.
+func (s *Store) Insert(p model.Product) error {
+	var err error
+	writeErr := s.write(func() bool {
+		if _, ok := s.products[p.ID]; ok {
+			err = ErrDuplicateKey
+			return false
+		}
+		s.products[p.ID] = clone(p)
+		return true
+	})
+	return errors.Join(err, writeErr)
+}
+
+// Get returns the product with the given ID, deleted or not.
+func (s *Store) Get(id primitive.ObjectID) (p model.Product, ok bool) {
+	s.read(func() {
+		p, ok = s.products[id]
+	})
+	return clone(p), ok
+}
+
+// Update runs fn on a copy of the product and stores the result if fn
+// returns true. The whole call is atomic. It reports whether the product
+// was written.
+func (s *Store) Update(id primitive.ObjectID, fn func(p *model.Product) bool) bool {
+	written := false
+	s.logError(s.write(func() bool {
+		p, ok := s.products[id]
+		if !ok {
+			return false
+		}
+		p = clone(p)
+		if !fn(&p) {
+			return false
+		}
+		p.ID = id
+		s.products[id] = p
+		written = true
+		return true
+	}))
+	return written
+}
+
+// DeleteWhere removes every product for which match returns true and
+// returns how many were removed.
+func (s *Store) DeleteWhere(match func(p model.Product) bool) int64 {
+	var n int64
+	s.logError(s.write(func() bool {
+		for id, p := range s.products {
+			if match(p) {
+				delete(s.products, id)
+				n++
+			}
+		}
+		return n > 0
+	}))
+	return n
+}
+
+// All returns every product, deleted or not, ordered by ID.
+func (s *Store) All() (products []model.Product) {
+	s.read(func() {
+		products = s.sorted()
+	})
+	return products
+}
+
+func (s *Store) sorted() []model.Product {
+	products := make([]model.Product, 0, len(s.products))
+	for _, p := range s.products {
+		products = append(products, clone(p))
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ID.Hex() < products[j].ID.Hex()
+	})
+	return products
+}
+
+// ApplySet applies a $set document to p the way MongoDB would, by going
+// through the BSON form of the product.
+func ApplySet(p model.Product, set bson.M) (model.Product, error) {
+	raw, err := bson.Marshal(p)
+	if err != nil {
+		return p, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return p, err
+	}
+	for k, v := range set {
+		doc[k] = v
+	}
+	if raw, err = bson.Marshal(doc); err != nil {
+		return p, err
+	}
+	var out model.Product
+	err = bson.Unmarshal(raw, &out)
+	return out, err
+}
+
+func clone(p model.Product) model.Product {
+	if p.DeletedAt != nil {
+		at := *p.DeletedAt
+		p.DeletedAt = &at
+	}
+	return p
+}
@@ -0,0 +1,250 @@
+package memstore
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStore_InsertAndGet(t *testing.T) {
+	// Arrange
+	store := New()
+	product := model.Product{ID: primitive.NewObjectID(), Name: "Laptop"}
+
+	// Act
+	err := store.Insert(product)
+	dupErr := store.Insert(product)
+	got, ok := store.Get(product.ID)
+
+	// Assert - Regla de negocio: El ID es único como en MongoDB
+	require.NoError(t, err)
+	assert.ErrorIs(t, dupErr, ErrDuplicateKey)
+	assert.True(t, ok)
+	assert.Equal(t, product, got)
+}
+
+func TestStore_ReturnsCopies(t *testing.T) {
+	// Arrange
+	store := New()
+	deletedAt := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	product := model.Product{ID: primitive.NewObjectID(), DeletedAt: &deletedAt}
+	require.NoError(t, store.Insert(product))
+
+	// Act
+	got, _ := store.Get(product.ID)
+	*got.DeletedAt = time.Time{}
+	again, _ := store.Get(product.ID)
+
+	// Assert - Regla de negocio: Modificar una copia no altera el almacén
+	assert.Equal(t, deletedAt, *again.DeletedAt)
+}
+
+func TestStore_Update(t *testing.T) {
+	// Arrange
+	store := New()
+	product := model.Product{ID: primitive.NewObjectID(), Stock: 1}
+	require.NoError(t, store.Insert(product))
+
+	// Act
+	skipped := store.Update(product.ID, func(p *model.Product) bool {
+		p.Stock = 99
+		return false
+	})
+	written := store.Update(product.ID, func(p *model.Product) bool {
+		p.Stock = 2
+		return true
+	})
+	missing := store.Update(primitive.NewObjectID(), func(*model.Product) bool { return true })
+
+	// Assert - Regla de negocio: Solo se guardan los cambios aceptados sobre productos existentes
+	got, _ := store.Get(product.ID)
+	assert.False(t, skipped)
+	assert.True(t, written)
+	assert.Equal(t, 2, got.Stock)
+	assert.False(t, missing)
+}
+
+func TestStore_ConcurrentUpdates(t *testing.T) {
+	// Arrange
+	store := New()
+	product := model.Product{ID: primitive.NewObjectID()}
+	require.NoError(t, store.Insert(product))
+
+	// Act
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.Update(product.ID, func(p *model.Product) bool {
+				p.Version++
+				return true
+			})
+		}()
+	}
+	wg.Wait()
+
+	// Assert - Regla de negocio: Las actualizaciones concurrentes no se pierden
+	got, _ := store.Get(product.ID)
+	assert.Equal(t, int64(100), got.Version)
+}
+
+func TestStore_DeleteWhereAndAll(t *testing.T) {
+	// Arrange
+	store := New()
+	for _, stock := range []int{0, 5, 0} {
+		require.NoError(t, store.Insert(model.Product{ID: primitive.NewObjectID(), Stock: stock}))
+	}
+
+	// Act
+	n := store.DeleteWhere(func(p model.Product) bool { return p.Stock == 0 })
+
+	// Assert - Regla de negocio: Solo se eliminan los productos que cumplen la condición
+	assert.Equal(t, int64(2), n)
+	require.Len(t, store.All(), 1)
+	assert.Equal(t, 5, store.All()[0].Stock)
+}
+
+func TestApplySet(t *testing.T) {
+	// Arrange
+	product := model.Product{ID: primitive.NewObjectID(), Name: "Laptop", Description: "Gaming", Price: 1500}
+
+	// Act
+	got, err := ApplySet(product, bson.M{"price": 1200.0, "description": ""})
+
+	// Assert - Regla de negocio: $set solo cambia los campos indicados
+	require.NoError(t, err)
+	assert.Equal(t, 1200.0, got.Price)
+	assert.Empty(t, got.Description)
+	assert.Equal(t, "Laptop", got.Name)
+}
+
+func TestFromEnv_Seed(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "seed.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name":"Laptop","price":1500},{"name":"Mouse","price":25}]`), 0o600))
+	t.Setenv(SeedFileEnv, path)
+
+	// Act
+	store, err := FromEnv()
+
+	// Assert - Regla de negocio: Los productos sin ID reciben uno nuevo
+	require.NoError(t, err)
+	products := store.All()
+	require.Len(t, products, 2)
+	assert.False(t, products[0].ID.IsZero())
+	assert.Equal(t, model.InitialVersion, products[0].Version)
+}
+
+func TestEnabled(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "mongo": false, "memory": true} {
+		// Arrange
+		t.Setenv(BackendEnv, value)
+
+		// Act
+		got, err := Enabled()
+
+		// Assert - Regla de negocio: Solo "memory" activa el almacén en memoria
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, value)
+	}
+}
+
+func TestEnabled_UnknownBackend(t *testing.T) {
+	// Arrange
+	t.Setenv(BackendEnv, "postgres")
+
+	// Act
+	_, err := Enabled()
+
+	// Assert - Regla de negocio: Un backend desconocido es un error
+	assert.Error(t, err)
+}
+
+func TestSharedStore_SeesOtherStores(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "store.bson")
+	fileA, err := OpenFile(path)
+	require.NoError(t, err)
+	fileB, err := OpenFile(path)
+	require.NoError(t, err)
+	a, b := NewShared(fileA), NewShared(fileB)
+	deletedAt := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	product := model.Product{ID: primitive.NewObjectID(), Name: "Laptop", Version: 1, DeletedAt: &deletedAt}
+
+	// Act
+	require.NoError(t, a.Insert(product))
+	got, ok := b.Get(product.ID)
+	updated := b.Update(product.ID, func(p *model.Product) bool {
+		p.Version++
+		return true
+	})
+	again, _ := a.Get(product.ID)
+
+	// Assert - Regla de negocio: Los procesos que comparten archivo ven las escrituras de los demás
+	assert.True(t, ok)
+	assert.Equal(t, product.Name, got.Name)
+	assert.True(t, deletedAt.Equal(*got.DeletedAt))
+	assert.True(t, updated)
+	assert.Equal(t, int64(2), again.Version)
+	assert.ErrorIs(t, b.Insert(product), ErrDuplicateKey)
+}
+
+func TestSharedStore_ConcurrentUpdates(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "store.bson")
+	var stores []*Store
+	for i := 0; i < 4; i++ {
+		file, err := OpenFile(path)
+		require.NoError(t, err)
+		stores = append(stores, NewShared(file))
+	}
+	product := model.Product{ID: primitive.NewObjectID()}
+	require.NoError(t, stores[0].Insert(product))
+
+	// Act
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(store *Store) {
+			defer wg.Done()
+			store.Update(product.ID, func(p *model.Product) bool {
+				p.Version++
+				return true
+			})
+		}(stores[i%len(stores)])
+	}
+	wg.Wait()
+
+	// Assert - Regla de negocio: Las escrituras de distintos procesos no se pierden
+	got, _ := stores[1].Get(product.ID)
+	assert.Equal(t, int64(40), got.Version)
+}
+
+func TestFromEnv_SharedSeedOnce(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	seed := filepath.Join(dir, "seed.json")
+	require.NoError(t, os.WriteFile(seed, []byte(`[{"name":"Laptop","price":1500}]`), 0o600))
+	t.Setenv(SeedFileEnv, seed)
+	t.Setenv(FileEnv, filepath.Join(dir, "store.bson"))
+
+	// Act
+	first, firstErr := FromEnv()
+	second, secondErr := FromEnv()
+
+	// Assert - Regla de negocio: Un archivo compartido se siembra una sola vez
+	require.NoError(t, firstErr)
+	require.NoError(t, secondErr)
+	assert.Len(t, first.All(), 1)
+	assert.Len(t, second.All(), 1)
+	assert.NotNil(t, second.File())
+}
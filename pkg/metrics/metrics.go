@@ -0,0 +1,233 @@
+// Package metrics exposes Prometheus metrics for the services: HTTP
+// requests, repository operations and the MongoDB connection pool.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/database"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Metrics holds the collectors of one service in its own registry.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	repoDuration     *prometheus.HistogramVec
+	repoErrors       *prometheus.CounterVec
+	poolOpen         prometheus.Gauge
+	poolInUse        prometheus.Gauge
+	poolCheckoutFail prometheus.Counter
+}
+
+// New registers the collectors, labelled with the service name, plus the
+// Go runtime and process collectors.
+func New(service string) *Metrics {
+	labels := prometheus.Labels{"service": service}
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "http_requests_total",
+			Help:        "HTTP requests handled, by route, method and status.",
+			ConstLabels: labels,
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "http_request_duration_seconds",
+			Help:        "HTTP request latency, by route, method and status.",
+			ConstLabels: labels,
+			Buckets:     prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "repository_operation_duration_seconds",
+			Help:        "Repository operation latency, by operation.",
+			ConstLabels: labels,
+			Buckets:     prometheus.DefBuckets,
+		}, []string{"operation"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "repository_operation_errors_total",
+			Help:        "Repository operations that failed, by operation.",
+			ConstLabels: labels,
+		}, []string{"operation"}),
+		poolOpen: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "mongo_pool_connections_open",
+			Help:        "Connections currently open in the MongoDB pool.",
+			ConstLabels: labels,
+		}),
+		poolInUse: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "mongo_pool_connections_in_use",
+			Help:        "Connections currently checked out of the MongoDB pool.",
+			ConstLabels: labels,
+		}),
+		poolCheckoutFail: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "mongo_pool_checkout_failures_total",
+			Help:        "Failed attempts to check a connection out of the MongoDB pool.",
+			ConstLabels: labels,
+		}),
+	}
+	m.Registry.MustRegister(
+		m.requests, m.requestDuration, m.repoDuration, m.repoErrors,
+		m.poolOpen, m.poolInUse, m.poolCheckoutFail,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{Registry: m.Registry})
+}
+
+// Middleware counts and times every request.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := server.NewStatusWriter(w)
+		next.ServeHTTP(sw, r)
+
+		route := Route(r.URL.Path)
+		status := strconv.Itoa(sw.Status())
+		m.requests.WithLabelValues(route, r.Method, status).Inc()
+		m.requestDuration.WithLabelValues(route, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveRepository records a repository operation that started at
+// start. It is meant to be deferred with a pointer to the named error
+// result. A document that is not found is not an error.
+func (m *Metrics) ObserveRepository(operation string, start time.Time, err *error) {
+	m.repoDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil && *err != nil && !errors.Is(*err, mongo.ErrNoDocuments) {
+		m.repoErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// ObserveRepositoryCall is ObserveRepository plus a debug log line through
+// the request-scoped logger. Errors other than a missing document or a
+// duplicate key are logged at error level.
+func (m *Metrics) ObserveRepositoryCall(ctx context.Context, operation string, start time.Time, err *error) {
+	m.ObserveRepository(operation, start, err)
+	level, attrs := slog.LevelDebug, []slog.Attr{
+		slog.String("operation", operation),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+	}
+	if *err != nil {
+		attrs = append(attrs, slog.String("error", (*err).Error()))
+		if !errors.Is(*err, mongo.ErrNoDocuments) && !mongo.IsDuplicateKeyError(*err) {
+			level = slog.LevelError
+		}
+	}
+	logging.FromContext(ctx).LogAttrs(ctx, level, "repository call", attrs...)
+}
+
+// PoolMonitor tracks the MongoDB connection pool.
+func (m *Metrics) PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				m.poolOpen.Inc()
+			case event.ConnectionClosed:
+				m.poolOpen.Dec()
+			case event.GetSucceeded:
+				m.poolInUse.Inc()
+			case event.ConnectionReturned:
+				m.poolInUse.Dec()
+			case event.GetFailed:
+				m.poolCheckoutFail.Inc()
+			}
+		},
+	}
+}
+
+const (
+	productRoute = "/products/{id}"
+	apiKeyRoute  = "/admin/api-keys/{id}"
+)
+
+var fixedRoutes = map[string]bool{
+	"/products": true, "/products:bulk": true, "/products/search": true, "/health": true,
+	"/livez": true, "/readyz": true, "/metrics": true,
+	"/admin/api-keys": true,
+}
+
+// productRoutes are the actions and sub-resources served under a product.
+var productRoutes = map[string]bool{
+	productRoute:                          true,
+	productRoute + ":restore":             true,
+	productRoute + ":rollback":            true,
+	productRoute + "/history":             true,
+	productRoute + "/revisions":           true,
+	productRoute + "/revisions/{version}": true,
+}
+
+// MongoOption attaches the pool monitor when connecting with
+// database.Open.
+func (m *Metrics) MongoOption() database.Option {
+	return func(opts *options.ClientOptions) {
+		opts.SetPoolMonitor(m.PoolMonitor())
+	}
+}
+
+// Route turns a request path into a low-cardinality route label: product
+// IDs become {id} and revision numbers {version}. Every path that is not a
+// known route becomes "other", so made-up paths never add label values,
+// span names or rate-limit buckets.
+func Route(path string) string {
+	if fixedRoutes[path] {
+		return path
+	}
+	if key, ok := strings.CutPrefix(path, "/admin/api-keys/"); ok && key != "" && !strings.Contains(key, "/") {
+		return apiKeyRoute
+	}
+	rest, ok := strings.CutPrefix(path, "/products/")
+	if !ok || rest == "" {
+		return "other"
+	}
+	segments := strings.Split(rest, "/")
+	id, action, hasAction := strings.Cut(segments[0], ":")
+	if id == "" {
+		return "other"
+	}
+	route := productRoute
+	if hasAction {
+		route += ":" + action
+	}
+	for _, seg := range segments[1:] {
+		if isNumber(seg) {
+			seg = "{version}"
+		}
+		route += "/" + seg
+	}
+	if !productRoutes[route] {
+		return "other"
+	}
+	return route
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestRoute(t *testing.T) {
+	id := "/products/507f1f77bcf86cd799439011"
+	cases := []struct {
+		path, want string
+	}{
+		{"/products", "/products"},
+		{"/products:bulk", "/products:bulk"},
+		{"/products/search", "/products/search"},
+		{"/readyz", "/readyz"},
+		{id, "/products/{id}"},
+		{"/products/not-an-id", "/products/{id}"},
+		{id + ":restore", "/products/{id}:restore"},
+		{id + ":rollback", "/products/{id}:rollback"},
+		{id + "/history", "/products/{id}/history"},
+		{id + "/revisions", "/products/{id}/revisions"},
+		{id + "/revisions/3", "/products/{id}/revisions/{version}"},
+		{"/admin/api-keys", "/admin/api-keys"},
+		{"/admin/api-keys/507f1f77bcf86cd799439011", "/admin/api-keys/{id}"},
+		{"/admin", "other"},
+		{"/products/", "other"},
+		{id + ":anything", "other"},
+		{id + "/a/b/zzz", "other"},
+		{id + "/revisions/latest", "other"},
+		{id + "/HISTORY", "other"},
+	}
+	for _, c := range cases {
+		// Act
+		route := Route(c.path)
+
+		// Assert - Regla de negocio: Cada ruta tiene una etiqueta propia y los IDs no crean series nuevas
+		assert.Equal(t, c.want, route, c.path)
+	}
+}
+
+func TestMiddleware_CountsByRouteMethodAndStatus(t *testing.T) {
+	// Arrange
+	m := New("test")
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ":bogus") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	// Act
+	for _, path := range []string{"/products", "/products", "/products/a:bogus", "/products/b:bogus"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, nil))
+	}
+
+	// Assert - Regla de negocio: Las rutas desconocidas no crean etiquetas nuevas
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("/products", http.MethodPost, "201")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues("other", http.MethodPost, "401")))
+}
+
+func TestObserveRepository(t *testing.T) {
+	// Arrange
+	m := New("test")
+
+	// Act
+	func() (err error) {
+		defer m.ObserveRepository("FindByID", time.Now(), &err)
+		return mongo.ErrNoDocuments
+	}()
+	func() (err error) {
+		defer m.ObserveRepository("FindByID", time.Now(), &err)
+		return errors.New("connection reset")
+	}()
+
+	// Assert - Regla de negocio: Un documento inexistente no cuenta como error
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.repoErrors.WithLabelValues("FindByID")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.repoDuration), "Ambas llamadas se registran en la misma serie")
+}
+
+func TestObserveRepositoryCall_LogLevels(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	ctx := logging.WithLogger(context.Background(), logging.NewWithWriter(&buf, "test", "debug"))
+	m := New("test")
+
+	// Act
+	func() (err error) {
+		defer m.ObserveRepositoryCall(ctx, "FindByID", time.Now(), &err)
+		return mongo.ErrNoDocuments
+	}()
+	func() (err error) {
+		defer m.ObserveRepositoryCall(ctx, "FindByID", time.Now(), &err)
+		return errors.New("connection reset")
+	}()
+
+	// Assert - Regla de negocio: Un documento inexistente se registra como DEBUG y los fallos reales como ERROR
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"level":"DEBUG"`)
+	assert.Contains(t, lines[1], `"level":"ERROR"`)
+	assert.Contains(t, lines[1], `"error":"connection reset"`)
+}
+
+func TestPoolMonitor(t *testing.T) {
+	// Arrange
+	m := New("test")
+	monitor := m.PoolMonitor()
+
+	// Act
+	for _, typ := range []string{event.ConnectionCreated, event.ConnectionCreated, event.GetSucceeded, event.GetFailed, event.ConnectionClosed} {
+		monitor.Event(&event.PoolEvent{Type: typ})
+	}
+
+	// Assert - Regla de negocio: El pool refleja conexiones abiertas, en uso y fallidas
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.poolOpen))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.poolInUse))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.poolCheckoutFail))
+}
+
+func TestHandler_ExposesMetrics(t *testing.T) {
+	// Arrange
+	m := New("create-service")
+	m.Middleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products", nil))
+
+	// Act
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Assert - Regla de negocio: Las métricas se exponen en formato Prometheus con el servicio como etiqueta
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `http_requests_total{method="GET",route="/products",service="create-service",status="404"} 1`)
+	assert.Contains(t, rec.Body.String(), "go_goroutines")
+}
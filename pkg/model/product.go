@@ -1,6 +1,14 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeletedAtField is the BSON field that marks a product as soft-deleted.
+const DeletedAtField = "deleted_at"
 
 type Product struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
@@ -8,4 +16,12 @@ type Product struct {
 	Description string             `bson:"description" json:"description"`
 	Price       float64            `bson:"price" json:"price"`
 	Stock       int                `bson:"stock" json:"stock"`
+	Version     int64              `bson:"version" json:"version"`
+	DeletedAt   *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// NotDeleted matches products that have not been soft-deleted. It also
+// matches documents written before soft delete existed.
+func NotDeleted() bson.M {
+	return bson.M{DeletedAtField: nil}
 }
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The files in testdata are the contract between the services: the
+// document create-service stores and the JSON every service returns.
+
+func contractProduct(t *testing.T) Product {
+	t.Helper()
+	id, err := primitive.ObjectIDFromHex("507f1f77bcf86cd799439011")
+	require.NoError(t, err)
+	return Product{ID: id, Name: "Laptop", Description: "Gaming laptop", Price: 1500, Stock: 10, Version: InitialVersion}
+}
+
+func TestProduct_DocumentContract(t *testing.T) {
+	// Arrange
+	golden, err := os.ReadFile("testdata/product_document.json")
+	require.NoError(t, err)
+
+	// Act
+	doc, marshalErr := bson.MarshalExtJSON(contractProduct(t), true, false)
+	var decoded Product
+	unmarshalErr := bson.UnmarshalExtJSON(golden, true, &decoded)
+
+	// Assert - Regla de negocio: El documento guardado en MongoDB no cambia sin actualizar el contrato
+	require.NoError(t, marshalErr)
+	require.NoError(t, unmarshalErr)
+	assert.JSONEq(t, string(golden), string(doc))
+	assert.Equal(t, contractProduct(t), decoded)
+}
+
+func TestProduct_JSONContract(t *testing.T) {
+	// Arrange
+	golden, err := os.ReadFile("testdata/product_response.json")
+	require.NoError(t, err)
+
+	// Act
+	body, marshalErr := json.Marshal(contractProduct(t))
+	var decoded Product
+	unmarshalErr := json.Unmarshal(golden, &decoded)
+
+	// Assert - Regla de negocio: Todos los servicios responden con el mismo JSON de producto
+	require.NoError(t, marshalErr)
+	require.NoError(t, unmarshalErr)
+	assert.JSONEq(t, string(golden), string(body))
+	assert.Equal(t, contractProduct(t), decoded)
+}
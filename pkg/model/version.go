@@ -0,0 +1,59 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// InitialVersion is the version of a newly created product. Every write
+// after that increments it by one.
+const InitialVersion int64 = 1
+
+var ErrInvalidETag = errors.New("invalid entity tag")
+
+// ETag formats a product version as a strong entity tag.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseIfMatch returns the versions listed in an If-Match header. It
+// returns nil for an empty header or "*", which only require the product
+// to exist. Weak tags never match, as RFC 9110 requires for If-Match.
+func ParseIfMatch(header string) ([]int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+	versions := []int64{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			return nil, ErrInvalidETag
+		}
+		v, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil {
+			return nil, ErrInvalidETag
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// VersionFilter matches documents at any of the given versions. Products
+// written before versioning have no version field and count as version 0.
+func VersionFilter(versions ...int64) bson.M {
+	in := bson.A{}
+	for _, v := range versions {
+		in = append(in, v)
+		if v == 0 {
+			in = append(in, nil)
+		}
+	}
+	return bson.M{"$in": in}
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	// Act
+	versions, err := ParseIfMatch(`"3", W/"4", "7"`)
+	wildcard, wildcardErr := ParseIfMatch("*")
+	_, invalidErr := ParseIfMatch("3")
+
+	// Assert - Regla de negocio: Las etiquetas débiles no cuentan para If-Match y * acepta cualquier versión
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 7}, versions)
+	assert.NoError(t, wildcardErr)
+	assert.Nil(t, wildcard)
+	assert.ErrorIs(t, invalidErr, ErrInvalidETag)
+	assert.Equal(t, `"12"`, ETag(12))
+}
+
+func TestVersionFilter_LegacyDocuments(t *testing.T) {
+	// Act
+	filter := VersionFilter(0, 2)
+
+	// Assert - Regla de negocio: La versión 0 también coincide con documentos sin versión
+	assert.Equal(t, bson.M{"$in": bson.A{int64(0), nil, int64(2)}}, filter)
+}
@@ -0,0 +1,207 @@
+// Package ratelimit throttles clients with token buckets. Each client,
+// identified by its API key, user or IP address, gets a bucket per route
+// that refills at a steady rate; a request takes one token or is refused
+// with 429 Too Many Requests.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+)
+
+// Environment variables read by FromEnv.
+const (
+	LimitEnv          = "RATE_LIMIT"
+	RoutesEnv         = "RATE_LIMIT_ROUTES"
+	TrustForwardedEnv = "RATE_LIMIT_TRUST_FORWARDED"
+)
+
+// Limit allows Requests per Per, with bursts of up to Requests.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Off reports whether the limit lets everything through.
+func (l Limit) Off() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+func (l Limit) String() string {
+	if l.Off() {
+		return "off"
+	}
+	return fmt.Sprintf("%d;w=%d", l.Requests, int(l.Per.Seconds()))
+}
+
+// ParseLimit reads a limit written as "<requests>/<s|m|h>", such as
+// "100/m", or "off".
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return Limit{}, nil
+	}
+	n, unit, ok := strings.Cut(s, "/")
+	requests, err := strconv.Atoi(n)
+	if !ok || err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: want <requests>/<s|m|h>", s)
+	}
+	per := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}[unit]
+	if per == 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: unit must be s, m or h", s)
+	}
+	return Limit{Requests: requests, Per: per}, nil
+}
+
+// Result is the state of a bucket after a Take.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next token, when refused.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the buckets. MemoryStore keeps them per process; a shared
+// store lets several replicas enforce one limit.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// Limiter applies Default, or the limit in Routes for the request's
+// method and route template (for example "GET /products/{id}"), to each
+// client.
+type Limiter struct {
+	Store   Store
+	Default Limit
+	Routes  map[string]Limit
+	// TrustForwarded takes the client address from the last
+	// X-Forwarded-For entry, as set by the gateway.
+	TrustForwarded bool
+	now            func() time.Time
+}
+
+// FromEnv builds a Limiter from RATE_LIMIT, RATE_LIMIT_ROUTES (a comma
+// separated list of "<METHOD> <route>=<limit>") and
+// RATE_LIMIT_TRUST_FORWARDED, backed by a MemoryStore. It returns nil
+// when no limit is set.
+func FromEnv() (*Limiter, error) {
+	def, err := ParseLimit(os.Getenv(LimitEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", LimitEnv, err)
+	}
+	routes := map[string]Limit{}
+	for _, entry := range strings.Split(os.Getenv(RoutesEnv), ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid entry %q: want <METHOD> <route>=<limit>", RoutesEnv, entry)
+		}
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", RoutesEnv, err)
+		}
+		routes[strings.Join(strings.Fields(route), " ")] = limit
+	}
+	var trust bool
+	if v := os.Getenv(TrustForwardedEnv); v != "" {
+		if trust, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("%s: %w", TrustForwardedEnv, err)
+		}
+	}
+	if def.Off() && len(routes) == 0 {
+		return nil, nil
+	}
+	return &Limiter{Store: NewMemoryStore(), Default: def, Routes: routes, TrustForwarded: trust}, nil
+}
+
+// Middleware takes a token for every request and answers 429 with
+// Retry-After when the bucket is empty. Limited responses carry the
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers. Store errors let the request through. A nil
+// Limiter disables limiting.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + metrics.Route(r.URL.Path)
+		limit, ok := l.Routes[route]
+		if !ok {
+			limit = l.Default
+		}
+		if limit.Off() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		res, err := l.Store.Take(r.Context(), l.key(r)+"|"+route, limit, l.clock())
+		if err != nil {
+			logging.FromContext(r.Context()).Error("rate limit store", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", seconds(res.Reset))
+		h.Set("RateLimit-Policy", limit.String())
+		if !res.Allowed {
+			h.Set("Retry-After", seconds(res.RetryAfter))
+			h.Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":"rate limit exceeded"}` + "\n"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// key identifies the client: the authenticated API key or user when the
+// request carries verified claims, its IP address otherwise.
+func (l *Limiter) key(r *http.Request) string {
+	if c, ok := auth.ClaimsFromContext(r.Context()); ok && c.Subject != "" {
+		if c.IsAPIKey() {
+			return "key:" + c.Subject
+		}
+		return "user:" + c.Subject
+	}
+	if l.TrustForwarded {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			hops := strings.Split(fwd[len(fwd)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return "ip:" + ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func (l *Limiter) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLimiter(now *time.Time, def Limit, routes map[string]Limit) http.Handler {
+	l := &Limiter{Store: NewMemoryStore(), Default: def, Routes: routes, now: func() time.Time { return *now }}
+	return l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+}
+
+func get(handler http.Handler, path, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware_RefusesWhenBucketIsEmpty(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	handler := testLimiter(&now, Limit{Requests: 2, Per: time.Minute}, nil)
+
+	// Act
+	first := get(handler, "/products", "10.0.0.1:1234")
+	second := get(handler, "/products", "10.0.0.1:1234")
+	third := get(handler, "/products", "10.0.0.1:1234")
+	other := get(handler, "/products", "10.0.0.2:1234")
+
+	// Assert - Regla de negocio: Un cliente que agota su cuota recibe 429 con Retry-After
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", first.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60", first.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, http.StatusTooManyRequests, third.Code)
+	assert.Equal(t, "30", third.Header().Get("Retry-After"))
+	assert.Equal(t, "0", third.Header().Get("RateLimit-Remaining"))
+	assert.JSONEq(t, `{"error":"rate limit exceeded"}`, third.Body.String())
+	assert.Equal(t, http.StatusOK, other.Code, "Cada cliente tiene su propia cuota")
+}
+
+func TestMiddleware_Refills(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	handler := testLimiter(&now, Limit{Requests: 1, Per: time.Second}, nil)
+	require.Equal(t, http.StatusOK, get(handler, "/products", "10.0.0.1:1").Code)
+	require.Equal(t, http.StatusTooManyRequests, get(handler, "/products", "10.0.0.1:1").Code)
+
+	// Act
+	now = now.Add(time.Second)
+	rec := get(handler, "/products", "10.0.0.1:1")
+
+	// Assert - Regla de negocio: La cuota se recupera con el tiempo
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestMiddleware_RouteLimits(t *testing.T) {
+	// Arrange
+	now := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	handler := testLimiter(&now, Limit{}, map[string]Limit{"GET /products": {Requests: 1, Per: time.Minute}})
+
+	// Act
+	list := get(handler, "/products", "10.0.0.1:1")
+	listAgain := get(handler, "/products", "10.0.0.1:1")
+	byID := get(handler, "/products/507f1f77bcf86cd799439011", "10.0.0.1:1")
+
+	// Assert - Regla de negocio: Cada ruta puede tener su propio límite
+	assert.Equal(t, http.StatusOK, list.Code)
+	assert.Equal(t, http.StatusTooManyRequests, listAgain.Code)
+	assert.Equal(t, http.StatusOK, byID.Code, "Las rutas sin límite propio usan el límite general")
+	assert.Empty(t, byID.Header().Get("RateLimit-Limit"))
+}
+
+func TestLimiter_Key(t *testing.T) {
+	l := &Limiter{TrustForwarded: true}
+	req := httptest.NewRequest(http.MethodGet, "/products", nil)
+	req.RemoteAddr = "172.18.0.5:5555"
+	assert.Equal(t, "ip:172.18.0.5", l.key(req))
+
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 203.0.113.7")
+	assert.Equal(t, "ip:203.0.113.7", l.key(req), "Se usa la dirección añadida por el gateway")
+
+	claims := &auth.Claims{}
+	claims.Subject = "ana"
+	assert.Equal(t, "user:ana", l.key(req.WithContext(auth.WithClaims(req.Context(), claims))))
+
+	key := &auth.Claims{Scopes: []auth.Operation{auth.OpRead}}
+	key.Subject = "apikey:1"
+	assert.Equal(t, "key:apikey:1", l.key(req.WithContext(auth.WithClaims(req.Context(), key))))
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, Limit, time.Time) (Result, error) {
+	return Result{}, errors.New("store unavailable")
+}
+
+func TestMiddleware_StoreErrorsFailOpen(t *testing.T) {
+	l := &Limiter{Store: failingStore{}, Default: Limit{Requests: 1, Per: time.Second}}
+	rec := get(l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})), "/products", "10.0.0.1:1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("100/m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 100, Per: time.Minute}, limit)
+
+	off, err := ParseLimit("off")
+	require.NoError(t, err)
+	assert.True(t, off.Off())
+
+	for _, bad := range []string{"100", "0/s", "x/m", "10/d"} {
+		_, err := ParseLimit(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(LimitEnv, "")
+	t.Setenv(RoutesEnv, "")
+	l, err := FromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, l, "Sin configuración no se limita")
+
+	t.Setenv(LimitEnv, "300/m")
+	t.Setenv(RoutesEnv, "GET  /products=60/m, POST /products:bulk=10/m")
+	l, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 300, Per: time.Minute}, l.Default)
+	assert.Equal(t, Limit{Requests: 60, Per: time.Minute}, l.Routes["GET /products"])
+	assert.Equal(t, Limit{Requests: 10, Per: time.Minute}, l.Routes["POST /products:bulk"])
+
+	t.Setenv(RoutesEnv, "GET /products")
+	_, err = FromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+// Package server runs the services' HTTP servers with timeouts, request
+// size limits and a graceful shutdown on SIGINT or SIGTERM.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultReadHeaderTimeout = 5 * time.Second
+	DefaultReadTimeout       = 15 * time.Second
+	DefaultWriteTimeout      = 30 * time.Second
+	DefaultIdleTimeout       = 60 * time.Second
+	DefaultShutdownTimeout   = 15 * time.Second
+	DefaultMaxHeaderBytes    = 1 << 20
+	// DefaultMaxBodyBytes leaves room for a full bulk request.
+	DefaultMaxBodyBytes = 8 << 20
+)
+
+type Config struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout bounds how long in-flight requests and closers may
+	// take once a signal arrives.
+	ShutdownTimeout time.Duration
+	MaxHeaderBytes  int
+	MaxBodyBytes    int64
+}
+
+// LoadConfig returns the defaults for addr, overridden by the HTTP_*
+// and SHUTDOWN_TIMEOUT environment variables.
+func LoadConfig(addr string) (Config, error) {
+	return LoadConfigFrom(addr, os.LookupEnv)
+}
+
+func LoadConfigFrom(addr string, lookup func(string) (string, bool)) (Config, error) {
+	cfg := Config{
+		Addr:              addr,
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		ReadTimeout:       DefaultReadTimeout,
+		WriteTimeout:      DefaultWriteTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+		ShutdownTimeout:   DefaultShutdownTimeout,
+		MaxHeaderBytes:    DefaultMaxHeaderBytes,
+		MaxBodyBytes:      DefaultMaxBodyBytes,
+	}
+
+	var errs []error
+	durations := []struct {
+		key string
+		dst *time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", &cfg.ReadHeaderTimeout},
+		{"HTTP_READ_TIMEOUT", &cfg.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", &cfg.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", &cfg.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout},
+	}
+	for _, d := range durations {
+		v, ok := lookup(d.key)
+		if !ok || v == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(v)
+		if err != nil || parsed <= 0 {
+			errs = append(errs, fmt.Errorf("invalid %s: %q", d.key, v))
+			continue
+		}
+		*d.dst = parsed
+	}
+
+	if v, ok := lookup("HTTP_MAX_HEADER_BYTES"); ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			errs = append(errs, fmt.Errorf("invalid HTTP_MAX_HEADER_BYTES: %q", v))
+		} else {
+			cfg.MaxHeaderBytes = n
+		}
+	}
+	if v, ok := lookup("HTTP_MAX_BODY_BYTES"); ok && v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			errs = append(errs, fmt.Errorf("invalid HTTP_MAX_BODY_BYTES: %q", v))
+		} else {
+			cfg.MaxBodyBytes = n
+		}
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// Closer releases a resource, such as a database client, once the server
+// has stopped accepting requests.
+type Closer func(ctx context.Context) error
+
+// NopCloser is a Closer with nothing to release.
+func NopCloser(context.Context) error { return nil }
+
+// Run serves handler until ctx is cancelled or the process receives
+// SIGINT or SIGTERM. It then stops accepting connections, waits for
+// in-flight requests and runs the closers in order, all within
+// cfg.ShutdownTimeout.
+func Run(ctx context.Context, cfg Config, handler http.Handler, closers ...Closer) error {
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		closeAll(context.Background(), cfg.ShutdownTimeout, closers)
+		return err
+	}
+	return Serve(ctx, ln, cfg, handler, closers...)
+}
+
+// Serve is Run on an existing listener.
+func Serve(ctx context.Context, ln net.Listener, cfg Config, handler http.Handler, closers ...Closer) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := New(cfg, handler)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		closeAll(context.Background(), cfg.ShutdownTimeout, closers)
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("shutting down, waiting up to %s for in-flight requests", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		err = fmt.Errorf("shutdown: %w", err)
+	}
+	return errors.Join(err, closeAll(shutdownCtx, 0, closers))
+}
+
+// New returns a server configured from cfg whose handler rejects bodies
+// larger than cfg.MaxBodyBytes.
+func New(cfg Config, handler http.Handler) *http.Server {
+	if cfg.MaxBodyBytes > 0 {
+		handler = http.MaxBytesHandler(handler, cfg.MaxBodyBytes)
+	}
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// BodyErrorStatus returns the status for an error reading a request
+// body: 413 when the body exceeded the size limit, 400 otherwise.
+func BodyErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func closeAll(ctx context.Context, timeout time.Duration, closers []Closer) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var errs []error
+	for _, c := range closers {
+		if err := c(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
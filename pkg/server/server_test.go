@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	// Act
+	cfg, err := LoadConfigFrom(":8081", lookup(nil))
+
+	// Assert - Regla de negocio: Sin variables se usan los límites por defecto
+	require.NoError(t, err)
+	assert.Equal(t, ":8081", cfg.Addr)
+	assert.Equal(t, DefaultReadHeaderTimeout, cfg.ReadHeaderTimeout)
+	assert.Equal(t, DefaultShutdownTimeout, cfg.ShutdownTimeout)
+	assert.Equal(t, int64(DefaultMaxBodyBytes), cfg.MaxBodyBytes)
+}
+
+func TestLoadConfig_Overrides(t *testing.T) {
+	// Arrange
+	env := lookup(map[string]string{
+		"HTTP_WRITE_TIMEOUT":    "45s",
+		"SHUTDOWN_TIMEOUT":      "2s",
+		"HTTP_MAX_HEADER_BYTES": "4096",
+		"HTTP_MAX_BODY_BYTES":   "1024",
+	})
+
+	// Act
+	cfg, err := LoadConfigFrom(":8081", env)
+
+	// Assert - Regla de negocio: Las variables de entorno sustituyen los valores por defecto
+	require.NoError(t, err)
+	assert.Equal(t, 45*time.Second, cfg.WriteTimeout)
+	assert.Equal(t, 2*time.Second, cfg.ShutdownTimeout)
+	assert.Equal(t, 4096, cfg.MaxHeaderBytes)
+	assert.Equal(t, int64(1024), cfg.MaxBodyBytes)
+}
+
+func TestLoadConfig_ReportsEveryInvalidValue(t *testing.T) {
+	// Arrange
+	env := lookup(map[string]string{
+		"HTTP_READ_TIMEOUT":   "soon",
+		"SHUTDOWN_TIMEOUT":    "-1s",
+		"HTTP_MAX_BODY_BYTES": "big",
+	})
+
+	// Act
+	_, err := LoadConfigFrom(":8081", env)
+
+	// Assert - Regla de negocio: Se informan todos los valores inválidos a la vez
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP_READ_TIMEOUT")
+	assert.Contains(t, err.Error(), "SHUTDOWN_TIMEOUT")
+	assert.Contains(t, err.Error(), "HTTP_MAX_BODY_BYTES")
+}
+
+func TestNew_LimitsBodySize(t *testing.T) {
+	// Arrange
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(BodyErrorStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := New(Config{MaxBodyBytes: 8}, handler)
+
+	// Act
+	small := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(small, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("1234")))
+	large := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(large, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("123456789")))
+
+	// Assert - Regla de negocio: Los cuerpos que superan el límite se rechazan con 413
+	assert.Equal(t, http.StatusOK, small.Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, large.Code)
+}
+
+func TestServe_DrainsInFlightRequestsAndCloses(t *testing.T) {
+	// Arrange
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = io.WriteString(w, "done")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	closed := false
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, ln, Config{ShutdownTimeout: time.Second}, handler, func(context.Context) error {
+			closed = true
+			return nil
+		})
+	}()
+
+	// Act
+	type result struct {
+		body string
+		err  error
+	}
+	resp := make(chan result, 1)
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			resp <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		resp <- result{string(body), err}
+	}()
+	<-started
+	cancel()
+
+	// Assert - Regla de negocio: Al apagarse, las peticiones en curso terminan y luego se cierran los recursos
+	got := <-resp
+	require.NoError(t, got.err)
+	assert.Equal(t, "done", got.body)
+	require.NoError(t, <-done)
+	assert.True(t, closed)
+}
+
+func TestServe_ShutdownDeadline(t *testing.T) {
+	// Arrange
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Serve(ctx, ln, Config{ShutdownTimeout: 50 * time.Millisecond}, handler)
+	}()
+	go func() {
+		if res, err := http.Get("http://" + ln.Addr().String()); err == nil {
+			res.Body.Close()
+		}
+	}()
+	<-started
+
+	// Act
+	cancel()
+
+	// Assert - Regla de negocio: El apagado no espera más que el plazo configurado
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return after the shutdown deadline")
+	}
+}
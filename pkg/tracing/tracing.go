@@ -0,0 +1,121 @@
+// Package tracing configures OpenTelemetry: the tracer provider and its
+// exporter, W3C trace-context propagation, and helpers to trace HTTP
+// handlers, outgoing requests, service methods and MongoDB commands.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/blandoncj/go-products-api/pkg/database"
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExporterEnv selects where spans go: none (the default), otlp or stdout.
+// The OTLP exporter is configured with the standard OTEL_EXPORTER_OTLP_*
+// variables and sampling with OTEL_TRACES_SAMPLER.
+const ExporterEnv = "OTEL_TRACES_EXPORTER"
+
+const instrumentationName = "github.com/blandoncj/go-products-api"
+
+// untraced paths are polled by probes and scrapers and would drown out
+// real requests.
+var untraced = map[string]bool{"/livez": true, "/readyz": true, "/metrics": true}
+
+// Setup installs the global tracer provider for service and the W3C
+// trace-context propagator. The returned Closer flushes pending spans.
+func Setup(ctx context.Context, service string) (server.Closer, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(ctx, os.Getenv(ExporterEnv))
+	if err != nil || exporter == nil {
+		return server.NopCloser, err
+	}
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults.
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(service)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, name string) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return nil, nil
+	case "otlp":
+		return otlptracehttp.New(ctx)
+	case "stdout", "console":
+		return stdouttrace.New()
+	default:
+		return nil, fmt.Errorf("%s: unknown exporter %q (want otlp, stdout or none)", ExporterEnv, name)
+	}
+}
+
+// Middleware starts a server span per request, continuing the trace sent
+// by the caller. Spans are named after the route template.
+func Middleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.server",
+		otelhttp.WithFilter(func(r *http.Request) bool { return !untraced[r.URL.Path] }),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + metrics.Route(r.URL.Path)
+		}),
+	)
+}
+
+// Transport traces outgoing requests and injects the trace context into
+// their headers.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
+
+// MongoOption traces every MongoDB command.
+func MongoOption() database.Option {
+	return func(opts *options.ClientOptions) {
+		opts.SetMonitor(otelmongo.NewMonitor())
+	}
+}
+
+// Start starts an internal span, typically for a service method.
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name)
+}
+
+// End ends span, marking it failed when *err is set. It is meant to be
+// deferred with a pointer to the named error result. A document that is
+// not found is not a failure.
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil && !errors.Is(*err, mongo.ErrNoDocuments) {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
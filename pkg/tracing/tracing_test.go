@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func record(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	// Arrange
+	recorder := record(t)
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := Start(r.Context(), "ProductService.GetByID")
+		span.End()
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/products/507f1f77bcf86cd799439011", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// Act
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert - Regla de negocio: Las peticiones continúan la traza del llamador
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "ProductService.GetByID", spans[0].Name())
+	assert.Equal(t, "GET /products/{id}", spans[1].Name(), "El span usa la plantilla de la ruta")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[1].SpanContext().TraceID().String())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
+
+func TestMiddleware_SkipsProbes(t *testing.T) {
+	// Arrange
+	recorder := record(t)
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Act
+	for _, path := range []string{"/livez", "/readyz", "/metrics"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Assert
+	assert.Empty(t, recorder.Ended())
+}
+
+func TestTransport_InjectsTraceContext(t *testing.T) {
+	// Arrange
+	record(t)
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer srv.Close()
+	ctx, span := Start(context.Background(), "gateway")
+	defer span.End()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+
+	// Act
+	res, err := (&http.Client{Transport: Transport(http.DefaultTransport)}).Do(req)
+
+	// Assert - Regla de negocio: El contexto de traza viaja al servicio de destino
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+}
+
+func TestEnd_MarksFailures(t *testing.T) {
+	// Arrange
+	recorder := record(t)
+	failure, notFound := errors.New("error de conexión"), mongo.ErrNoDocuments
+
+	// Act
+	_, span := Start(context.Background(), "failed")
+	End(span, &failure)
+	_, span = Start(context.Background(), "not-found")
+	End(span, &notFound)
+
+	// Assert - Regla de negocio: Un documento inexistente no es un fallo
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestSetup_Exporters(t *testing.T) {
+	t.Setenv(ExporterEnv, "none")
+	closer, err := Setup(context.Background(), "test-service")
+	require.NoError(t, err)
+	assert.NoError(t, closer(context.Background()))
+
+	t.Setenv(ExporterEnv, "stdout")
+	closer, err = Setup(context.Background(), "test-service")
+	require.NoError(t, err)
+	assert.NoError(t, closer(context.Background()))
+
+	t.Setenv(ExporterEnv, "zipkin")
+	_, err = Setup(context.Background(), "test-service")
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package validation
+
+import "github.com/blandoncj/go-products-api/pkg/model"
+
+const (
+	MaxNameLength        = 200
+	MaxDescriptionLength = 2000
+)
+
+// ProductRules returns the default rules for model.Product, keyed by the
+// JSON field names. Callers may override any field with Field.
+func ProductRules() *Validator {
+	return New().
+		Field("name", Required(), MaxLength(MaxNameLength)).
+		Field("description", MaxLength(MaxDescriptionLength)).
+		Field("price", Min(0)).
+		Field("stock", Min(0))
+}
+
+func ProductValues(p model.Product) map[string]any {
+	return map[string]any{
+		"name":        p.Name,
+		"description": p.Description,
+		"price":       p.Price,
+		"stock":       p.Stock,
+	}
+}
@@ -0,0 +1,116 @@
+// Package validation checks request values against per-field rules. A
+// Validator runs every rule and returns all the failures at once as
+// Errors, which controllers render as 422 Unprocessable Entity.
+// ProductRules holds the rules shared by the services that write products.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors collects every rule a value failed. It is returned as an error so
+// services can hand it back unchanged and controllers can render it as 422.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Rule checks a single field value and returns a message when it fails.
+type Rule struct {
+	Name  string
+	Check func(value any) (string, bool)
+}
+
+type Validator struct {
+	fields []string
+	rules  map[string][]Rule
+}
+
+func New() *Validator {
+	return &Validator{rules: map[string][]Rule{}}
+}
+
+// Field sets the rules for a field, replacing any rules configured before.
+func (v *Validator) Field(name string, rules ...Rule) *Validator {
+	if _, ok := v.rules[name]; !ok {
+		v.fields = append(v.fields, name)
+	}
+	v.rules[name] = rules
+	return v
+}
+
+// Validate runs the rules of every field present in values, so a partial
+// update is only checked for the fields it changes.
+func (v *Validator) Validate(values map[string]any) error {
+	var errs Errors
+	for _, field := range v.fields {
+		value, ok := values[field]
+		if !ok {
+			continue
+		}
+		for _, rule := range v.rules[field] {
+			if msg, ok := rule.Check(value); !ok {
+				errs = append(errs, FieldError{Field: field, Rule: rule.Name, Message: msg})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func Required() Rule {
+	return Rule{Name: "required", Check: func(value any) (string, bool) {
+		s, ok := value.(string)
+		return "must not be empty", !ok || strings.TrimSpace(s) != ""
+	}}
+}
+
+func MaxLength(n int) Rule {
+	return Rule{Name: "max_length", Check: func(value any) (string, bool) {
+		s, ok := value.(string)
+		return fmt.Sprintf("must be at most %d characters", n), !ok || utf8.RuneCountInString(s) <= n
+	}}
+}
+
+func Min(min float64) Rule {
+	return Rule{Name: "min", Check: func(value any) (string, bool) {
+		f, ok := number(value)
+		return fmt.Sprintf("must be greater than or equal to %v", min), !ok || f >= min
+	}}
+}
+
+func Max(max float64) Rule {
+	return Rule{Name: "max", Check: func(value any) (string, bool) {
+		f, ok := number(value)
+		return fmt.Sprintf("must be less than or equal to %v", max), !ok || f <= max
+	}}
+}
+
+func number(value any) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,72 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductRules_ValidProduct(t *testing.T) {
+	// Arrange
+	product := model.Product{Name: "Laptop", Description: "High performance laptop", Price: 1500.00, Stock: 0}
+
+	// Act
+	err := ProductRules().Validate(ProductValues(product))
+
+	// Assert - Regla de negocio: Un producto válido no debe generar errores
+	assert.NoError(t, err)
+}
+
+func TestProductRules_FieldErrors(t *testing.T) {
+	// Arrange
+	product := model.Product{
+		Name:        "  ",
+		Description: strings.Repeat("a", MaxDescriptionLength+1),
+		Price:       -1,
+		Stock:       -5,
+	}
+
+	// Act
+	err := ProductRules().Validate(ProductValues(product))
+
+	// Assert - Regla de negocio: Cada campo inválido se informa con la regla que incumple
+	var errs Errors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, []string{"name", "description", "price", "stock"}, fields(errs))
+	assert.Equal(t, "required", errs[0].Rule)
+	assert.Equal(t, "max_length", errs[1].Rule)
+	assert.Equal(t, "min", errs[2].Rule)
+}
+
+func TestValidator_PartialValues(t *testing.T) {
+	// Act
+	err := ProductRules().Validate(map[string]any{"price": 10.0})
+
+	// Assert - Regla de negocio: Los campos ausentes no se validan
+	assert.NoError(t, err)
+}
+
+func TestValidator_OverrideField(t *testing.T) {
+	// Arrange
+	v := ProductRules().Field("stock", Min(0), Max(100))
+
+	// Act
+	err := v.Validate(map[string]any{"stock": 500})
+
+	// Assert - Regla de negocio: Las reglas de un campo pueden reemplazarse
+	var errs Errors
+	assert.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, FieldError{Field: "stock", Rule: "max", Message: "must be less than or equal to 100"}, errs[0])
+}
+
+func fields(errs Errors) []string {
+	var out []string
+	for _, fe := range errs {
+		out = append(out, fe.Field)
+	}
+	return out
+}
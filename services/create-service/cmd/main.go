@@ -1,12 +1,29 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/apikey"
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/database"
+	"github.com/blandoncj/go-products-api/pkg/health"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/ratelimit"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
+	"github.com/blandoncj/go-products-api/pkg/validation"
 	"github.com/blandoncj/go-products-api/services/create-service/internal/controller"
+	"github.com/blandoncj/go-products-api/services/create-service/internal/repository"
+	"github.com/blandoncj/go-products-api/services/create-service/internal/service"
 )
 
 func main() {
@@ -14,9 +31,93 @@ func main() {
 	if port == "" {
 		port = "8081"
 	}
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := health.Probe(context.Background(), "http://127.0.0.1:"+port+"/readyz"); err != nil {
+			log.Fatalf("create service: %v", err)
+		}
+		return
+	}
+
+	logger := logging.New("create-service")
+	slog.SetDefault(logger)
+
+	cfg, err := server.LoadConfig(fmt.Sprintf(":%s", port))
+	if err != nil {
+		log.Fatalf("create service: %v", err)
+	}
+
+	closeTracing, err := tracing.Setup(context.Background(), "create-service")
+	if err != nil {
+		log.Fatalf("create service: %v", err)
+	}
+	verifier, err := auth.FromEnv()
+	if err != nil {
+		log.Fatalf("create service: %v", err)
+	}
+	if verifier == nil {
+		log.Printf("Create service running without authentication")
+	}
+	limiter, err := ratelimit.FromEnv()
+	if err != nil {
+		log.Fatalf("create service: %v", err)
+	}
+	m := metrics.New("create-service")
+	repo, stores, checks, closeRepo, err := newRepository(m)
+	if err != nil {
+		log.Fatalf("create service: %v", err)
+	}
+	keyManager := apikey.NewManager(stores.keys)
+	if verifier != nil {
+		verifier.APIKeys = keyManager
+	}
+	repo = repository.NewInstrumentedRepository(repo, m)
+	svc := &service.ProductService{Repo: repo, Rules: validation.ProductRules(), Audit: audit.NewRecorder(stores.audit)}
+
+	handler := http.NewServeMux()
+	health.NewChecker("create-service", checks...).Register(handler)
+	handler.Handle("/metrics", m.Handler())
+	admin := auth.RequireRole(verifier, auth.RoleAdmin)(limiter.Middleware(apikey.NewAdminHandler(keyManager)))
+	handler.Handle(apikey.AdminPath, admin)
+	handler.Handle(apikey.AdminPath+"/", admin)
+	handler.Handle("/", auth.Require(verifier, auth.OpCreate)(limiter.Middleware(controller.NewHandler(svc))))
+	log.Printf("Create service listening on %s", cfg.Addr)
+	root := tracing.Middleware(logging.Middleware(logger)(m.Middleware(handler)))
+	if err := server.Run(context.Background(), cfg, root, closeRepo, closeTracing); err != nil {
+		log.Fatalf("create service: %v", err)
+	}
+	log.Printf("Create service stopped")
+}
+
+func newRepository(m *metrics.Metrics) (repository.ProductRepositoryInterface, storeSet, []health.Check, server.Closer, error) {
+	memory, err := memstore.Enabled()
+	if err != nil {
+		return nil, storeSet{}, nil, nil, err
+	}
+	if memory {
+		store, err := memstore.FromEnv()
+		if err != nil {
+			return nil, storeSet{}, nil, nil, err
+		}
+		log.Printf("Create service using in-memory storage")
+		return repository.NewMemoryRepository(store), storeSet{keys: apikey.NewSharedMemoryStore(store.File()), audit: audit.NewSharedMemoryStore(store.File())}, nil, server.NopCloser, nil
+	}
+
+	db, err := database.Open(context.Background(), m.MongoOption(), tracing.MongoOption())
+	if err != nil {
+		return nil, storeSet{}, nil, nil, err
+	}
+	keys := apikey.NewMongoStore(db)
+	ctxIdx, cancelIdx := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelIdx()
+	if err := keys.EnsureIndexes(ctxIdx); err != nil {
+		_ = db.Client().Disconnect(context.Background())
+		return nil, storeSet{}, nil, nil, fmt.Errorf("create indexes: %w", err)
+	}
+	return repository.NewProductRepository(db), storeSet{keys: keys, audit: audit.NewMongoStore(db)}, []health.Check{database.HealthCheck(db)}, db.Client().Disconnect, nil
+}
 
-	handler := controller.NewHandler()
-	addr := fmt.Sprintf(":%s", port)
-	log.Printf("Create service listening on %s", addr)
-	log.Fatal(http.ListenAndServe(addr, handler))
+// storeSet holds the stores the service keeps next to its products.
+type storeSet struct {
+	keys  apikey.Store
+	audit audit.Store
 }
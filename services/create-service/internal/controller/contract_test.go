@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// contractDir holds the documents shared with read-service's contract test.
+const contractDir = "../../../../pkg/model/testdata/"
+
+func TestContract_CreateWritesCanonicalDocument(t *testing.T) {
+	// Arrange
+	repo := newFakeRepository()
+	goldenDoc, err := os.ReadFile(contractDir + "product_document.json")
+	require.NoError(t, err)
+	goldenBody, err := os.ReadFile(contractDir + "product_response.json")
+	require.NoError(t, err)
+	contractID, _ := primitive.ObjectIDFromHex("507f1f77bcf86cd799439011")
+
+	// Act
+	rec := serve(repo, http.MethodPost, "/products", `{"name":"Laptop","description":"Gaming laptop","price":1500,"stock":10}`)
+
+	// Assert - Regla de negocio: El documento guardado y la respuesta siguen el contrato compartido
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.Len(t, repo.products, 1)
+	for id, stored := range repo.products {
+		stored.ID = contractID
+		doc, err := bson.MarshalExtJSON(stored, true, false)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(goldenDoc), string(doc))
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, id.Hex(), body["id"])
+		body["id"] = contractID.Hex()
+		normalized, _ := json.Marshal(body)
+		assert.JSONEq(t, string(goldenBody), string(normalized))
+	}
+}
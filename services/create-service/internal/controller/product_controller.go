@@ -1,46 +1,18 @@
 package controller
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
-	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/bulk"
 	"github.com/blandoncj/go-products-api/pkg/model"
-	"github.com/blandoncj/go-products-api/services/create-service/internal/repository"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"github.com/blandoncj/go-products-api/pkg/validation"
 	"github.com/blandoncj/go-products-api/services/create-service/internal/service"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func NewHandler() http.Handler {
-	user := os.Getenv("MONGO_ROOT_USERNAME")
-	pass := os.Getenv("MONGO_ROOT_PASSWORD")
-	host := os.Getenv("MONGO_HOST")
-	dbName := os.Getenv("MONGO_DB")
-
-	mongoURI := fmt.Sprintf("mongodb://%s:%s@%s:27017/?authSource=admin", user, pass, host)
-
-	clientOpts := options.Client().ApplyURI(mongoURI)
-
-	client, err := mongo.Connect(context.Background(), clientOpts)
-	if err != nil {
-		panic(fmt.Sprintf("error connecting to MongoDB: %v", err))
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	if err := client.Ping(ctx, nil); err != nil {
-		panic(fmt.Sprintf("cannot ping MongoDB: %v", err))
-	}
-
-	db := client.Database(dbName)
-	repo := repository.NewProductRepository(db)
-	svc := &service.ProductService{Repo: repo}
-
+func NewHandler(svc *service.ProductService) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -55,16 +27,70 @@ func NewHandler() http.Handler {
 		}
 		var product model.Product
 		if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			writeError(w, server.BodyErrorStatus(err), "invalid json: "+err.Error())
 			return
 		}
-		if err := svc.Create(r.Context(), product); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		created, err := svc.Create(r.Context(), product)
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			writeValidationError(w, verrs)
 			return
 		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "create error: "+err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/products/"+created.ID.Hex())
+		w.Header().Set("ETag", model.ETag(created.Version))
 		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(product)
+		json.NewEncoder(w).Encode(created)
+	})
+
+	mux.HandleFunc("/products:bulk", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		ordered, err := bulk.ParseOrdered(r.URL.Query().Get("ordered"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var products []model.Product
+		if err := json.NewDecoder(r.Body).Decode(&products); err != nil {
+			writeError(w, server.BodyErrorStatus(err), "invalid json: "+err.Error())
+			return
+		}
+		res, err := svc.CreateMany(r.Context(), products, ordered)
+		if errors.Is(err, bulk.ErrTooManyItems) {
+			writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "create error: "+err.Error())
+			return
+		}
+		status := http.StatusCreated
+		if res.HasFailures() {
+			status = http.StatusMultiStatus
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(res)
 	})
 
 	return mux
 }
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+func writeValidationError(w http.ResponseWriter, errs validation.Errors) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": "validation failed", "fields": errs})
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/services/create-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeRepository keeps products in a map. Setting err makes every call
+// fail, like a lost database connection.
+type fakeRepository struct {
+	mu       sync.Mutex
+	products map[primitive.ObjectID]model.Product
+	err      error
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{products: map[primitive.ObjectID]model.Product{}}
+}
+
+func (f *fakeRepository) Create(ctx context.Context, product model.Product) (*mongo.InsertOneResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	product.ID = primitive.NewObjectID()
+	f.products[product.ID] = product
+	return &mongo.InsertOneResult{InsertedID: product.ID}, nil
+}
+
+func (f *fakeRepository) CreateMany(ctx context.Context, products []model.Product, ordered bool) (*mongo.InsertManyResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	res := &mongo.InsertManyResult{}
+	for _, p := range products {
+		f.products[p.ID] = p
+		res.InsertedIDs = append(res.InsertedIDs, p.ID)
+	}
+	return res, nil
+}
+
+func serve(repo *fakeRepository, method, target, body string) *httptest.ResponseRecorder {
+	handler := NewHandler(&service.ProductService{Repo: repo})
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_Health(t *testing.T) {
+	rec := serve(newFakeRepository(), http.MethodGet, "/health", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandler_Create_Success(t *testing.T) {
+	// Arrange
+	repo := newFakeRepository()
+
+	// Act
+	rec := serve(repo, http.MethodPost, "/products", `{"name":"Laptop","price":1500,"stock":10}`)
+
+	// Assert - Regla de negocio: Crear responde 201 con la ubicación y la versión del producto
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created model.Product
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "/products/"+created.ID.Hex(), rec.Header().Get("Location"))
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+	assert.Equal(t, "Laptop", repo.products[created.ID].Name)
+}
+
+func TestHandler_Create_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		body   string
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodGet, "", nil, http.StatusMethodNotAllowed},
+		{"json invalido", http.MethodPost, `{"name":`, nil, http.StatusBadRequest},
+		{"producto invalido", http.MethodPost, `{"name":"","price":-1}`, nil, http.StatusUnprocessableEntity},
+		{"error de base de datos", http.MethodPost, `{"name":"Laptop","price":1}`, errors.New("error de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo := newFakeRepository()
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, "/products", c.body)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+			assert.Empty(t, repo.products)
+			if c.status != http.StatusMethodNotAllowed {
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "Los errores se devuelven en JSON")
+			}
+		})
+	}
+}
+
+func TestHandler_Create_BodyTooLarge(t *testing.T) {
+	// Arrange
+	repo := newFakeRepository()
+	handler := http.MaxBytesHandler(NewHandler(&service.ProductService{Repo: repo}), 16)
+	req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{"name":"Laptop","price":1500}`))
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(rec, req)
+
+	// Assert - Regla de negocio: Un cuerpo mayor al límite del servidor se rechaza con 413
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Empty(t, repo.products)
+}
+
+func TestHandler_Create_ValidationBody(t *testing.T) {
+	// Act
+	rec := serve(newFakeRepository(), http.MethodPost, "/products", `{"name":"Laptop","price":-1}`)
+
+	// Assert - Regla de negocio: Los errores de validación indican el campo y la regla
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{"error":"validation failed","fields":[{"field":"price","rule":"min","message":"must be greater than or equal to 0"}]}`, rec.Body.String())
+}
+
+func TestHandler_CreateMany_AllSucceed(t *testing.T) {
+	// Arrange
+	repo := newFakeRepository()
+
+	// Act
+	rec := serve(repo, http.MethodPost, "/products:bulk", `[{"name":"Laptop","price":1500},{"name":"Mouse","price":25}]`)
+
+	// Assert - Regla de negocio: Un lote sin fallos responde 201
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var res bulk.Result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, 2, res.Succeeded)
+	assert.Len(t, repo.products, 2)
+}
+
+func TestHandler_CreateMany_PartialFailure(t *testing.T) {
+	// Arrange
+	repo := newFakeRepository()
+
+	// Act
+	rec := serve(repo, http.MethodPost, "/products:bulk?ordered=false", `[{"name":"Laptop","price":1500},{"name":""}]`)
+
+	// Assert - Regla de negocio: Un lote con fallos responde 207 con el detalle por ítem
+	require.Equal(t, http.StatusMultiStatus, rec.Code)
+	var res bulk.Result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, 1, res.Succeeded)
+	assert.Equal(t, 1, res.Failed)
+	assert.Len(t, repo.products, 1)
+}
+
+func TestHandler_CreateMany_Errors(t *testing.T) {
+	tooMany := "[" + strings.Repeat(`{"name":"x"},`, bulk.MaxItems) + `{"name":"x"}]`
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodPut, "/products:bulk", "[]", nil, http.StatusMethodNotAllowed},
+		{"ordered invalido", http.MethodPost, "/products:bulk?ordered=maybe", "[]", nil, http.StatusBadRequest},
+		{"json invalido", http.MethodPost, "/products:bulk", `{"name":"Laptop"}`, nil, http.StatusBadRequest},
+		{"demasiados items", http.MethodPost, "/products:bulk", tooMany, nil, http.StatusRequestEntityTooLarge},
+		{"error de base de datos", http.MethodPost, "/products:bulk", `[{"name":"Laptop"}]`, errors.New("error de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo := newFakeRepository()
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, c.target, c.body)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+			assert.Empty(t, repo.products)
+			if c.status != http.StatusMethodNotAllowed {
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "Los errores se devuelven en JSON")
+			}
+		})
+	}
+}
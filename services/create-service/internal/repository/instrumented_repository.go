@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InstrumentedRepository records the duration and errors of every call to
+// the repository it wraps.
+type InstrumentedRepository struct {
+	next    ProductRepositoryInterface
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedRepository(next ProductRepositoryInterface, m *metrics.Metrics) *InstrumentedRepository {
+	return &InstrumentedRepository{next: next, metrics: m}
+}
+
+func (r *InstrumentedRepository) Create(ctx context.Context, product model.Product) (res *mongo.InsertOneResult, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "Create", time.Now(), &err)
+	return r.next.Create(ctx, product)
+}
+
+func (r *InstrumentedRepository) CreateMany(ctx context.Context, products []model.Product, ordered bool) (res *mongo.InsertManyResult, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "CreateMany", time.Now(), &err)
+	return r.next.CreateMany(ctx, products, ordered)
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInstrumentedRepository_RecordsOperations(t *testing.T) {
+	// Arrange
+	m := metrics.New("create-service")
+	repo := NewInstrumentedRepository(NewMemoryRepository(memstore.New()), m)
+	product := model.Product{ID: primitive.NewObjectID(), Name: "Laptop"}
+
+	// Act
+	_, err := repo.Create(context.Background(), product)
+	require.NoError(t, err)
+	_, dupErr := repo.Create(context.Background(), product)
+
+	// Assert - Regla de negocio: Cada operación se mide y los fallos se cuentan
+	assert.Error(t, dupErr)
+	count, err := testutil.GatherAndCount(m.Registry, "repository_operation_duration_seconds", "repository_operation_errors_total")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "Una serie de duración y una de errores para Create")
+}
+
+func TestInstrumentedRepository_LogsWithRequestLogger(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := logging.NewWithWriter(&buf, "create-service", "debug").With("request_id", "req-1")
+	ctx := logging.WithLogger(context.Background(), logger)
+	repo := NewInstrumentedRepository(NewMemoryRepository(memstore.New()), metrics.New("create-service"))
+
+	// Act
+	_, err := repo.Create(ctx, model.Product{ID: primitive.NewObjectID(), Name: "Laptop"})
+
+	// Assert - Regla de negocio: Las llamadas al repositorio se registran con el ID de la petición
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), `"request_id":"req-1"`)
+	assert.Contains(t, buf.String(), `"operation":"Create"`)
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// duplicateKeyCode is the server error code for unique index violations.
+const duplicateKeyCode = 11000
+
+// MemoryRepository stores products in a memstore.Store instead of MongoDB.
+type MemoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) *MemoryRepository {
+	return &MemoryRepository{store: store}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, product model.Product) (*mongo.InsertOneResult, error) {
+	if product.ID.IsZero() {
+		product.ID = primitive.NewObjectID()
+	}
+	if err := r.store.Insert(product); err != nil {
+		return nil, mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: duplicateKeyCode, Message: err.Error()}}}
+	}
+	return &mongo.InsertOneResult{InsertedID: product.ID}, nil
+}
+
+func (r *MemoryRepository) CreateMany(ctx context.Context, products []model.Product, ordered bool) (*mongo.InsertManyResult, error) {
+	res := &mongo.InsertManyResult{}
+	var writeErrs []mongo.BulkWriteError
+	for i, product := range products {
+		if product.ID.IsZero() {
+			product.ID = primitive.NewObjectID()
+		}
+		if err := r.store.Insert(product); err != nil {
+			writeErrs = append(writeErrs, mongo.BulkWriteError{
+				WriteError: mongo.WriteError{Index: i, Code: duplicateKeyCode, Message: err.Error()},
+			})
+			if ordered {
+				break
+			}
+			continue
+		}
+		res.InsertedIDs = append(res.InsertedIDs, product.ID)
+	}
+	if len(writeErrs) > 0 {
+		return res, mongo.BulkWriteException{WriteErrors: writeErrs}
+	}
+	return res, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMemoryRepository_Create(t *testing.T) {
+	// Arrange
+	store := memstore.New()
+	repo := NewMemoryRepository(store)
+	product := model.Product{Name: "Laptop", Price: 1500}
+
+	// Act
+	res, err := repo.Create(context.Background(), product)
+
+	// Assert - Regla de negocio: El producto queda guardado con un ID generado
+	require.NoError(t, err)
+	id := res.InsertedID.(primitive.ObjectID)
+	saved, ok := store.Get(id)
+	assert.True(t, ok)
+	assert.Equal(t, "Laptop", saved.Name)
+}
+
+func TestMemoryRepository_Create_DuplicateID(t *testing.T) {
+	// Arrange
+	repo := NewMemoryRepository(memstore.New())
+	product := model.Product{ID: primitive.NewObjectID(), Name: "Laptop"}
+	_, err := repo.Create(context.Background(), product)
+	require.NoError(t, err)
+
+	// Act
+	_, err = repo.Create(context.Background(), product)
+
+	// Assert - Regla de negocio: Un ID repetido falla como en MongoDB
+	assert.True(t, mongo.IsDuplicateKeyError(err))
+}
+
+func TestMemoryRepository_CreateMany_Ordered(t *testing.T) {
+	// Arrange
+	repo := NewMemoryRepository(memstore.New())
+	dup := primitive.NewObjectID()
+	products := []model.Product{{ID: dup}, {ID: dup}, {}}
+
+	// Act
+	res, err := repo.CreateMany(context.Background(), products, true)
+
+	// Assert - Regla de negocio: En modo ordenado la inserción se detiene en el primer fallo
+	writeErrs, ok := bulk.WriteErrors(err)
+	require.True(t, ok)
+	assert.Contains(t, writeErrs, 1)
+	assert.Len(t, res.InsertedIDs, 1)
+}
+
+func TestMemoryRepository_CreateMany_Unordered(t *testing.T) {
+	// Arrange
+	repo := NewMemoryRepository(memstore.New())
+	dup := primitive.NewObjectID()
+	products := []model.Product{{ID: dup}, {ID: dup}, {}}
+
+	// Act
+	res, err := repo.CreateMany(context.Background(), products, false)
+
+	// Assert - Regla de negocio: En modo no ordenado se insertan los demás
+	writeErrs, ok := bulk.WriteErrors(err)
+	require.True(t, ok)
+	assert.Len(t, writeErrs, 1)
+	assert.Len(t, res.InsertedIDs, 2)
+}
@@ -3,11 +3,14 @@ package repository
 import (
 	"context"
 
+	"github.com/blandoncj/go-products-api/pkg/model"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ProductRepositoryInterface interface {
-	Create(ctx context.Context, product any) error
+	Create(ctx context.Context, product model.Product) (*mongo.InsertOneResult, error)
+	CreateMany(ctx context.Context, products []model.Product, ordered bool) (*mongo.InsertManyResult, error)
 }
 
 type ProductRepository struct {
@@ -18,7 +21,14 @@ func NewProductRepository(db *mongo.Database) *ProductRepository {
 	return &ProductRepository{Collection: db.Collection("products")}
 }
 
-func (r *ProductRepository) Create(ctx context.Context, product any) error {
-	_, err := r.Collection.InsertOne(ctx, product)
-	return err
+func (r *ProductRepository) Create(ctx context.Context, product model.Product) (*mongo.InsertOneResult, error) {
+	return r.Collection.InsertOne(ctx, product)
+}
+
+func (r *ProductRepository) CreateMany(ctx context.Context, products []model.Product, ordered bool) (*mongo.InsertManyResult, error) {
+	docs := make([]any, len(products))
+	for i, p := range products {
+		docs[i] = p
+	}
+	return r.Collection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(ordered))
 }
@@ -2,14 +2,113 @@ package service
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
+	"github.com/blandoncj/go-products-api/pkg/validation"
 	"github.com/blandoncj/go-products-api/services/create-service/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ProductService struct {
 	Repo repository.ProductRepositoryInterface
+	// Rules defaults to validation.ProductRules when nil.
+	Rules *validation.Validator
+	// Audit records every product created; nil records nothing.
+	Audit *audit.Recorder
 }
 
-func (s *ProductService) Create(ctx context.Context, product any) error {
-	return s.Repo.Create(ctx, product)
+// Create validates and stores the product at its initial version,
+// returning it with the ObjectID generated on insert. Any ID, version or
+// deletion mark sent by the client is ignored.
+func (s *ProductService) Create(ctx context.Context, product model.Product) (_ *model.Product, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.Create")
+	defer tracing.End(span, &err)
+
+	if err := s.rules().Validate(validation.ProductValues(product)); err != nil {
+		return nil, err
+	}
+
+	product.ID = primitive.NilObjectID
+	product.Version = model.InitialVersion
+	product.DeletedAt = nil
+	res, err := s.Repo.Create(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("unexpected inserted id type %T", res.InsertedID)
+	}
+	product.ID = id
+	if err := s.Audit.Record(ctx, audit.Changed(audit.ActionCreate, nil, &product)); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// CreateMany validates every product and inserts the valid ones in one
+// InsertMany call. In ordered mode nothing after the first failure is
+// inserted.
+func (s *ProductService) CreateMany(ctx context.Context, products []model.Product, ordered bool) (_ *bulk.Result, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.CreateMany")
+	defer tracing.End(span, &err)
+
+	if len(products) > bulk.MaxItems {
+		return nil, bulk.ErrTooManyItems
+	}
+	res := bulk.NewResult(len(products), ordered)
+
+	var batch []model.Product
+	var indexes []int
+	for i, product := range products {
+		if err := s.rules().Validate(validation.ProductValues(product)); err != nil {
+			res.Fail(i, err)
+			if ordered {
+				break
+			}
+			continue
+		}
+		product.ID = primitive.NewObjectID()
+		product.Version = model.InitialVersion
+		product.DeletedAt = nil
+		batch = append(batch, product)
+		indexes = append(indexes, i)
+	}
+	if len(batch) == 0 {
+		return res.Finish(), nil
+	}
+
+	_, err = s.Repo.CreateMany(ctx, batch, ordered)
+	writeErrs, ok := bulk.WriteErrors(err)
+	if err != nil && !ok {
+		return nil, err
+	}
+	var created []audit.Mutation
+	for j, i := range indexes {
+		if werr, failed := writeErrs[j]; failed {
+			res.Fail(i, werr)
+			if ordered {
+				break
+			}
+			continue
+		}
+		res.SetID(i, batch[j].ID.Hex())
+		res.Succeed(i)
+		created = append(created, audit.Changed(audit.ActionCreate, nil, &batch[j]))
+	}
+	if err := s.Audit.Record(ctx, created...); err != nil {
+		return nil, err
+	}
+	return res.Finish(), nil
+}
+
+func (s *ProductService) rules() *validation.Validator {
+	if s.Rules == nil {
+		return validation.ProductRules()
+	}
+	return s.Rules
 }
@@ -5,36 +5,80 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/pkg/validation"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type MockProductRepository struct {
 	mock.Mock
 }
 
-func (m *MockProductRepository) Create(ctx context.Context, product any) error {
+func (m *MockProductRepository) Create(ctx context.Context, product model.Product) (*mongo.InsertOneResult, error) {
 	args := m.Called(ctx, product)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*mongo.InsertOneResult), args.Error(1)
+}
+
+func (m *MockProductRepository) CreateMany(ctx context.Context, products []model.Product, ordered bool) (*mongo.InsertManyResult, error) {
+	args := m.Called(ctx, products, ordered)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*mongo.InsertManyResult), args.Error(1)
 }
 
 func TestProductService_Create_Success(t *testing.T) {
 	mockRepo := new(MockProductRepository)
 	service := &ProductService{Repo: mockRepo}
 	ctx := context.Background()
+	insertedID := primitive.NewObjectID()
 
-	product := map[string]any{
-		"name":        "Laptop",
-		"description": "High performance laptop",
-		"price":       1500.00,
-		"stock":       10,
+	product := model.Product{
+		Name:        "Laptop",
+		Description: "High performance laptop",
+		Price:       1500.00,
+		Stock:       10,
 	}
 
-	mockRepo.On("Create", ctx, product).Return(nil)
+	stored := product
+	stored.Version = model.InitialVersion
 
-	err := service.Create(ctx, product)
+	mockRepo.On("Create", mock.Anything, stored).Return(&mongo.InsertOneResult{InsertedID: insertedID}, nil)
+
+	created, err := service.Create(ctx, product)
 
 	assert.NoError(t, err, "El producto debe crearse sin errores")
+	assert.Equal(t, insertedID, created.ID, "Debe retornar el ID generado")
+	assert.Equal(t, model.InitialVersion, created.Version, "Todo producto nuevo inicia en la versión 1")
+	assert.Equal(t, "Laptop", created.Name)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_Create_IgnoresClientID(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := &ProductService{Repo: mockRepo}
+	ctx := context.Background()
+	insertedID := primitive.NewObjectID()
+
+	product := model.Product{ID: primitive.NewObjectID(), Name: "Mouse", Price: 25.00, Stock: 50, Version: 9}
+	stored := product
+	stored.ID = primitive.NilObjectID
+	stored.Version = model.InitialVersion
+
+	mockRepo.On("Create", mock.Anything, stored).Return(&mongo.InsertOneResult{InsertedID: insertedID}, nil)
+
+	created, err := service.Create(ctx, product)
+
+	assert.NoError(t, err)
+	assert.Equal(t, insertedID, created.ID, "El ID lo genera la base de datos, no el cliente")
 	mockRepo.AssertExpectations(t)
 }
 
@@ -43,18 +87,50 @@ func TestProductService_Create_InvalidProduct(t *testing.T) {
 	service := &ProductService{Repo: mockRepo}
 	ctx := context.Background()
 
-	invalidProduct := map[string]any{
-		"name":  "Invalid Product",
-		"price": -100.00,
-		"stock": 5,
+	invalidProduct := model.Product{
+		Name:  "Invalid Product",
+		Price: -100.00,
+		Stock: 5,
 	}
 
-	mockRepo.On("Create", ctx, invalidProduct).Return(errors.New("precio no puede ser negativo"))
+	created, err := service.Create(ctx, invalidProduct)
+
+	var verrs validation.Errors
+	assert.True(t, errors.As(err, &verrs), "Debe fallar cuando el precio es negativo")
+	assert.Equal(t, "price", verrs[0].Field)
+	assert.Equal(t, "min", verrs[0].Rule)
+	assert.Nil(t, created)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProductService_Create_CustomRules(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	rules := validation.ProductRules().Field("stock", validation.Min(1))
+	service := &ProductService{Repo: mockRepo, Rules: rules}
+	ctx := context.Background()
 
-	err := service.Create(ctx, invalidProduct)
+	created, err := service.Create(ctx, model.Product{Name: "Pre-order", Price: 10.00, Stock: 0})
+
+	var verrs validation.Errors
+	assert.True(t, errors.As(err, &verrs), "Las reglas configuradas reemplazan las de por defecto")
+	assert.Equal(t, "stock", verrs[0].Field)
+	assert.Nil(t, created)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProductService_Create_DatabaseError(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := &ProductService{Repo: mockRepo}
+	ctx := context.Background()
+	product := model.Product{Name: "Laptop", Price: 1500.00, Stock: 10}
 
-	assert.Error(t, err, "Debe fallar cuando el precio es negativo")
-	assert.Contains(t, err.Error(), "precio no puede ser negativo")
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil, errors.New("error de conexión"))
+
+	created, err := service.Create(ctx, product)
+
+	assert.Error(t, err, "Errores de BD deben propagarse")
+	assert.Contains(t, err.Error(), "conexión")
+	assert.Nil(t, created)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -63,16 +139,193 @@ func TestProductService_Create_StockZero(t *testing.T) {
 	service := &ProductService{Repo: mockRepo}
 	ctx := context.Background()
 
-	product := map[string]any{
-		"name":  "Out of Stock Product",
-		"price": 50.00,
-		"stock": 0,
+	product := model.Product{
+		Name:  "Out of Stock Product",
+		Price: 50.00,
+		Stock: 0,
 	}
 
-	mockRepo.On("Create", ctx, product).Return(nil)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(p model.Product) bool {
+		return p.Stock == 0
+	})).Return(&mongo.InsertOneResult{InsertedID: primitive.NewObjectID()}, nil)
 
-	err := service.Create(ctx, product)
+	_, err := service.Create(ctx, product)
 
 	assert.NoError(t, err, "Producto con stock 0 puede crearse (para pre-ordenes)")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestProductService_CreateMany_AllSucceed(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := &ProductService{Repo: mockRepo}
+	ctx := context.Background()
+
+	products := []model.Product{
+		{Name: "Laptop", Price: 1500.00, Stock: 10},
+		{Name: "Mouse", Price: 25.00, Stock: 50},
+	}
+
+	mockRepo.On("CreateMany", mock.Anything, mock.MatchedBy(func(batch []model.Product) bool {
+		return len(batch) == 2 && !batch[0].ID.IsZero() && batch[1].Version == model.InitialVersion
+	}), true).Return(&mongo.InsertManyResult{}, nil)
+
+	res, err := service.CreateMany(ctx, products, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.Succeeded)
+	assert.False(t, res.HasFailures())
+	assert.NotEmpty(t, res.Items[0].ID, "Cada producto creado debe reportar su ID")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_CreateMany_UnorderedPartialFailure(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := &ProductService{Repo: mockRepo}
+	ctx := context.Background()
+
+	products := []model.Product{
+		{Name: "Laptop", Price: 1500.00, Stock: 10},
+		{Name: "", Price: 25.00, Stock: 50},
+		{Name: "Keyboard", Price: 75.00, Stock: 30},
+	}
+	writeErr := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}},
+	}}
+
+	mockRepo.On("CreateMany", mock.Anything, mock.MatchedBy(func(batch []model.Product) bool {
+		return len(batch) == 2 && batch[0].Name == "Laptop" && batch[1].Name == "Keyboard"
+	}), false).Return(&mongo.InsertManyResult{}, writeErr)
+
+	res, err := service.CreateMany(ctx, products, false)
+
+	// Regla de negocio: En modo no ordenado los fallos no detienen el resto del lote
+	assert.NoError(t, err)
+	assert.Equal(t, bulk.StatusFailed, res.Items[0].Status)
+	assert.Equal(t, "duplicate key", res.Items[0].Error)
+	assert.Equal(t, bulk.StatusFailed, res.Items[1].Status)
+	assert.Equal(t, "name", res.Items[1].Fields[0].Field)
+	assert.Equal(t, bulk.StatusSucceeded, res.Items[2].Status)
+	assert.Equal(t, 1, res.Succeeded)
+	assert.Equal(t, 2, res.Failed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_CreateMany_OrderedStopsAtFirstFailure(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := &ProductService{Repo: mockRepo}
+	ctx := context.Background()
+
+	products := []model.Product{
+		{Name: "Laptop", Price: 1500.00, Stock: 10},
+		{Name: "Mouse", Price: -25.00, Stock: 50},
+		{Name: "Keyboard", Price: 75.00, Stock: 30},
+	}
+
+	mockRepo.On("CreateMany", mock.Anything, mock.MatchedBy(func(batch []model.Product) bool {
+		return len(batch) == 1 && batch[0].Name == "Laptop"
+	}), true).Return(&mongo.InsertManyResult{}, nil)
+
+	res, err := service.CreateMany(ctx, products, true)
+
+	// Regla de negocio: En modo ordenado nada después del primer fallo se inserta
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bulk.StatusSucceeded, bulk.StatusFailed, bulk.StatusSkipped}, statuses(res))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_CreateMany_DatabaseError(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := &ProductService{Repo: mockRepo}
+	ctx := context.Background()
+
+	mockRepo.On("CreateMany", mock.Anything, mock.Anything, true).Return(nil, errors.New("error de conexión"))
+
+	res, err := service.CreateMany(ctx, []model.Product{{Name: "Laptop", Price: 1500.00}}, true)
+
+	assert.Error(t, err, "Errores de BD que afectan todo el lote deben propagarse")
+	assert.Nil(t, res)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_CreateMany_TooManyItems(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := &ProductService{Repo: mockRepo}
+
+	_, err := service.CreateMany(context.Background(), make([]model.Product, bulk.MaxItems+1), false)
+
+	assert.ErrorIs(t, err, bulk.ErrTooManyItems)
+	mockRepo.AssertNotCalled(t, "CreateMany", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductService_Create_RecordsAudit(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	store := audit.NewMemoryStore()
+	service := &ProductService{Repo: mockRepo, Audit: audit.NewRecorder(store)}
+	insertedID := primitive.NewObjectID()
+
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(&mongo.InsertOneResult{InsertedID: insertedID}, nil)
+
+	_, err := service.Create(context.Background(), model.Product{Name: "Laptop", Price: 1500.00, Stock: 10})
+
+	// Regla de negocio: Toda creación queda registrada en la auditoría
+	assert.NoError(t, err)
+	entries, _ := store.History(context.Background(), insertedID, primitive.NilObjectID, 10)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, audit.ActionCreate, entries[0].Action)
+		assert.Equal(t, audit.Anonymous, entries[0].Actor)
+		assert.Contains(t, entries[0].Changes, audit.Change{Field: "name", After: "Laptop"})
+	}
+}
+
+type failingAuditStore struct{ *audit.MemoryStore }
+
+func (failingAuditStore) Insert(context.Context, ...audit.Entry) error {
+	return errors.New("error de conexión")
+}
+
+func TestProductService_Create_AuditFailure(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := &ProductService{Repo: mockRepo, Audit: audit.NewRecorder(failingAuditStore{audit.NewMemoryStore()})}
+
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(&mongo.InsertOneResult{InsertedID: primitive.NewObjectID()}, nil)
+
+	product, err := service.Create(context.Background(), model.Product{Name: "Laptop", Price: 1500.00, Stock: 10})
+
+	// Regla de negocio: Una creación que no se puede auditar se informa como fallida
+	assert.ErrorContains(t, err, "error de conexión")
+	assert.Nil(t, product)
+}
+
+func TestProductService_CreateMany_AuditsOnlyInsertedProducts(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	store := audit.NewMemoryStore()
+	service := &ProductService{Repo: mockRepo, Audit: audit.NewRecorder(store)}
+	var batch []model.Product
+	writeErr := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}},
+	}}
+
+	mockRepo.On("CreateMany", mock.Anything, mock.Anything, false).Run(func(args mock.Arguments) {
+		batch = args.Get(1).([]model.Product)
+	}).Return(&mongo.InsertManyResult{}, writeErr)
+
+	_, err := service.CreateMany(context.Background(), []model.Product{
+		{Name: "Laptop", Price: 1500.00, Stock: 10},
+		{Name: "Keyboard", Price: 75.00, Stock: 30},
+	}, false)
+
+	// Regla de negocio: Solo los productos insertados generan entradas de auditoría
+	assert.NoError(t, err)
+	failed, _ := store.History(context.Background(), batch[0].ID, primitive.NilObjectID, 10)
+	inserted, _ := store.History(context.Background(), batch[1].ID, primitive.NilObjectID, 10)
+	assert.Empty(t, failed)
+	assert.Len(t, inserted, 1)
+}
+
+func statuses(res *bulk.Result) []string {
+	var out []string
+	for _, item := range res.Items {
+		out = append(out, item.Status)
+	}
+	return out
+}
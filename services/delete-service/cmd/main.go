@@ -1,11 +1,28 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/apikey"
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/database"
+	"github.com/blandoncj/go-products-api/pkg/health"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/ratelimit"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
 	"github.com/blandoncj/go-products-api/services/delete-service/internal/controller"
+	"github.com/blandoncj/go-products-api/services/delete-service/internal/repository"
+	"github.com/blandoncj/go-products-api/services/delete-service/internal/service"
 )
 
 func main() {
@@ -13,9 +30,129 @@ func main() {
 	if p := GetEnv("DELETE_SERVICE_PORT", "8084"); p != "" {
 		port = p
 	}
-	handler := controller.NewHandler()
-	log.Printf("Delete service listening on :%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, handler))
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := health.Probe(context.Background(), "http://127.0.0.1:"+port+"/readyz"); err != nil {
+			log.Fatalf("delete service: %v", err)
+		}
+		return
+	}
+
+	logger := logging.New("delete-service")
+	slog.SetDefault(logger)
+
+	cfg, err := server.LoadConfig(":" + port)
+	if err != nil {
+		log.Fatalf("delete service: %v", err)
+	}
+	closeTracing, err := tracing.Setup(context.Background(), "delete-service")
+	if err != nil {
+		log.Fatalf("delete service: %v", err)
+	}
+	verifier, err := auth.FromEnv()
+	if err != nil {
+		log.Fatalf("delete service: %v", err)
+	}
+	if verifier == nil {
+		log.Printf("Delete service running without authentication")
+	}
+	limiter, err := ratelimit.FromEnv()
+	if err != nil {
+		log.Fatalf("delete service: %v", err)
+	}
+	m := metrics.New("delete-service")
+	repo, stores, checks, closeRepo, err := newRepository(m)
+	if err != nil {
+		log.Fatalf("delete service: %v", err)
+	}
+	keyManager := apikey.NewManager(stores.keys)
+	if verifier != nil {
+		verifier.APIKeys = keyManager
+	}
+	repo = repository.NewInstrumentedRepository(repo, m)
+	svc, purgeInterval, err := newService(repo, stores.audit)
+	if err != nil {
+		log.Fatalf("delete service: %v", err)
+	}
+	stopPurge := startPurge(svc, purgeInterval)
+
+	handler := http.NewServeMux()
+	health.NewChecker("delete-service", checks...).Register(handler)
+	handler.Handle("/metrics", m.Handler())
+	handler.Handle("/", auth.Require(verifier, auth.OpDelete)(limiter.Middleware(controller.NewHandler(svc))))
+	log.Printf("Delete service listening on %s", cfg.Addr)
+	root := tracing.Middleware(logging.Middleware(logger)(m.Middleware(handler)))
+	if err := server.Run(context.Background(), cfg, root, stopPurge, closeRepo, closeTracing); err != nil {
+		log.Fatalf("delete service: %v", err)
+	}
+	log.Printf("Delete service stopped")
+}
+
+// startPurge runs the purge loop in the background. The returned Closer
+// stops it and waits for a purge in progress to finish.
+func startPurge(svc *service.ProductService, interval time.Duration) server.Closer {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.RunPurge(ctx, interval)
+	}()
+	return func(shutdownCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	}
+}
+
+func newService(repo repository.ProductRepositoryInterface, history audit.Store) (*service.ProductService, time.Duration, error) {
+	var err error
+	svc := service.NewProductService(repo)
+	svc.Audit = audit.NewRecorder(history)
+	if v := os.Getenv("SOFT_DELETE_RETENTION"); v != "" {
+		retention, err := time.ParseDuration(v)
+		if err != nil || retention <= 0 {
+			return nil, 0, fmt.Errorf("invalid SOFT_DELETE_RETENTION: %q", v)
+		}
+		svc.Retention = retention
+	}
+	purgeInterval := time.Hour
+	if v := os.Getenv("PURGE_INTERVAL"); v != "" {
+		purgeInterval, err = time.ParseDuration(v)
+		if err != nil || purgeInterval <= 0 {
+			return nil, 0, fmt.Errorf("invalid PURGE_INTERVAL: %q", v)
+		}
+	}
+	return svc, purgeInterval, nil
+}
+
+func newRepository(m *metrics.Metrics) (repository.ProductRepositoryInterface, storeSet, []health.Check, server.Closer, error) {
+	memory, err := memstore.Enabled()
+	if err != nil {
+		return nil, storeSet{}, nil, nil, err
+	}
+	if memory {
+		store, err := memstore.FromEnv()
+		if err != nil {
+			return nil, storeSet{}, nil, nil, err
+		}
+		log.Printf("Delete service using in-memory storage")
+		return repository.NewMemoryRepository(store), storeSet{keys: apikey.NewSharedMemoryStore(store.File()), audit: audit.NewSharedMemoryStore(store.File())}, nil, server.NopCloser, nil
+	}
+
+	db, err := database.Open(context.Background(), m.MongoOption(), tracing.MongoOption())
+	if err != nil {
+		return nil, storeSet{}, nil, nil, err
+	}
+	return repository.NewDeleteRepository(db), storeSet{keys: apikey.NewMongoStore(db), audit: audit.NewMongoStore(db)}, []health.Check{database.HealthCheck(db)}, db.Client().Disconnect, nil
+}
+
+// storeSet holds the stores the service keeps next to its products.
+type storeSet struct {
+	keys  apikey.Store
+	audit audit.Store
 }
 
 func GetEnv(key, def string) string {
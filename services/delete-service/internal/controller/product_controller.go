@@ -1,47 +1,19 @@
 package controller
 
 import (
-	"context"
-	"fmt"
+	"encoding/json"
+	"errors"
 	"net/http"
-	"os"
-	"time"
+	"strings"
 
-	"github.com/blandoncj/go-products-api/services/delete-service/internal/repository"
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/pkg/server"
 	"github.com/blandoncj/go-products-api/services/delete-service/internal/service"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func NewHandler() http.Handler {
-	user := os.Getenv("MONGO_ROOT_USERNAME")
-	pass := os.Getenv("MONGO_ROOT_PASSWORD")
-	host := os.Getenv("MONGO_HOST")
-	port := os.Getenv("MONGO_PORT")
-	if port == "" {
-		port = "27017"
-	}
-	dbname := os.Getenv("MONGO_DB")
-
-	mongoURI := fmt.Sprintf("mongodb://%s:%s@%s:%s/?authSource=admin", user, pass, host, port)
-	clientOpts := options.Client().ApplyURI(mongoURI)
-	ctxConn, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	client, err := mongo.Connect(ctxConn, clientOpts)
-	if err != nil {
-		panic(err)
-	}
-	ctxPing, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelPing()
-	if err := client.Ping(ctxPing, nil); err != nil {
-		panic(err)
-	}
-
-	db := client.Database(dbname)
-	repo := repository.NewDeleteRepository(db)
-	svc := service.NewProductService(repo)
-
+func NewHandler(svc *service.ProductService) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -49,23 +21,105 @@ func NewHandler() http.Handler {
 	})
 
 	mux.HandleFunc("/products/", func(w http.ResponseWriter, r *http.Request) {
+		idHex := r.URL.Path[len("/products/"):]
+		if idHex, ok := strings.CutSuffix(idHex, ":restore"); ok {
+			restoreProduct(w, r, svc, idHex)
+			return
+		}
 		if r.Method != http.MethodDelete {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		idHex := r.URL.Path[len("/products/"):]
 		objID, err := primitive.ObjectIDFromHex(idHex)
 		if err != nil {
-			http.Error(w, "invalid id format", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, "invalid id format")
 			return
 		}
-		if err := svc.DeleteProduct(r.Context(), objID); err != nil {
-			http.Error(w, "delete error: "+err.Error(), http.StatusInternalServerError)
+		ifMatch, err := model.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid If-Match header")
 			return
 		}
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status":"deleted"}`))
+		err = svc.DeleteProduct(r.Context(), objID, ifMatch)
+		if errors.Is(err, service.ErrProductNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrVersionMismatch) {
+			writeError(w, http.StatusPreconditionFailed, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "delete error: "+err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	})
+
+	mux.HandleFunc("/products:bulk", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		ordered, err := bulk.ParseOrdered(r.URL.Query().Get("ordered"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var payload struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			writeError(w, server.BodyErrorStatus(err), "invalid json: "+err.Error())
+			return
+		}
+		res, err := svc.DeleteMany(r.Context(), payload.IDs, ordered)
+		if errors.Is(err, bulk.ErrTooManyItems) {
+			writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "delete error: "+err.Error())
+			return
+		}
+		status := http.StatusOK
+		if res.HasFailures() {
+			status = http.StatusMultiStatus
+		}
+		writeJSON(w, status, res)
 	})
 
 	return mux
 }
+
+func restoreProduct(w http.ResponseWriter, r *http.Request, svc *service.ProductService, idHex string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	objID, err := primitive.ObjectIDFromHex(idHex)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id format")
+		return
+	}
+	err = svc.RestoreProduct(r.Context(), objID)
+	if errors.Is(err, service.ErrProductNotFound) {
+		writeError(w, http.StatusNotFound, "deleted product not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "restore error: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "restored"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
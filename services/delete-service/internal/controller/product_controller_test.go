@@ -0,0 +1,293 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/services/delete-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeRepository keeps products in a map and applies the same filters as
+// the Mongo repository. Setting err makes every call fail.
+type fakeRepository struct {
+	mu       sync.Mutex
+	products map[primitive.ObjectID]model.Product
+	err      error
+}
+
+func newFakeRepository(products ...model.Product) *fakeRepository {
+	f := &fakeRepository{products: map[primitive.ObjectID]model.Product{}}
+	for _, p := range products {
+		f.products[p.ID] = p
+	}
+	return f
+}
+
+func (f *fakeRepository) SoftDeleteByID(ctx context.Context, id any, versions []int64, at time.Time) (*model.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	p, ok := f.products[id.(primitive.ObjectID)]
+	if !ok || p.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	if versions != nil && !slices.Contains(versions, p.Version) {
+		return nil, mongo.ErrNoDocuments
+	}
+	f.markDeleted(p, at)
+	return &p, nil
+}
+
+func (f *fakeRepository) ExistingIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	existing := map[primitive.ObjectID]bool{}
+	for _, id := range ids {
+		if p, ok := f.products[id]; ok && p.DeletedAt == nil {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
+
+func (f *fakeRepository) RestoreByID(ctx context.Context, id any) (*model.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	p, ok := f.products[id.(primitive.ObjectID)]
+	if !ok || p.DeletedAt == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	before := p
+	p.DeletedAt = nil
+	p.Version++
+	f.products[p.ID] = p
+	return &before, nil
+}
+
+func (f *fakeRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]model.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	purged := []model.Product{}
+	for id, p := range f.products {
+		if p.DeletedAt != nil && p.DeletedAt.Before(before) {
+			delete(f.products, id)
+			purged = append(purged, p)
+		}
+	}
+	return purged, nil
+}
+
+func (f *fakeRepository) markDeleted(p model.Product, at time.Time) {
+	p.DeletedAt = &at
+	p.Version++
+	f.products[p.ID] = p
+}
+
+func serve(repo *fakeRepository, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	handler := NewHandler(service.NewProductService(repo))
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func laptop() model.Product {
+	return model.Product{ID: primitive.NewObjectID(), Name: "Laptop", Price: 1500, Stock: 10, Version: 2}
+}
+
+func TestHandler_Health(t *testing.T) {
+	rec := serve(newFakeRepository(), http.MethodGet, "/health", "", nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandler_Delete(t *testing.T) {
+	// Arrange
+	product := laptop()
+	repo := newFakeRepository(product)
+
+	// Act
+	rec := serve(repo, http.MethodDelete, "/products/"+product.ID.Hex(), "", http.Header{"If-Match": {`"2"`}})
+
+	// Assert - Regla de negocio: Eliminar marca el producto sin borrarlo
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"deleted"}`, rec.Body.String())
+	assert.NotNil(t, repo.products[product.ID].DeletedAt)
+}
+
+func TestHandler_Delete_Errors(t *testing.T) {
+	product := laptop()
+	target := "/products/" + product.ID.Hex()
+	cases := []struct {
+		name   string
+		method string
+		target string
+		header http.Header
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodGet, target, nil, nil, http.StatusMethodNotAllowed},
+		{"id invalido", http.MethodDelete, "/products/abc", nil, nil, http.StatusBadRequest},
+		{"if-match invalido", http.MethodDelete, target, http.Header{"If-Match": {"2"}}, nil, http.StatusBadRequest},
+		{"no encontrado", http.MethodDelete, "/products/" + primitive.NewObjectID().Hex(), nil, nil, http.StatusNotFound},
+		{"version obsoleta", http.MethodDelete, target, http.Header{"If-Match": {`"1"`}}, nil, http.StatusPreconditionFailed},
+		{"error de base de datos", http.MethodDelete, target, nil, errors.New("error de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo := newFakeRepository(product)
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, c.target, "", c.header)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+			assert.Nil(t, repo.products[product.ID].DeletedAt, "El producto no debe eliminarse")
+		})
+	}
+}
+
+func TestHandler_Restore(t *testing.T) {
+	// Arrange
+	product := laptop()
+	deletedAt := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	product.DeletedAt = &deletedAt
+	repo := newFakeRepository(product)
+
+	// Act
+	rec := serve(repo, http.MethodPost, "/products/"+product.ID.Hex()+":restore", "", nil)
+
+	// Assert - Regla de negocio: Restaurar quita la marca de borrado
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"restored"}`, rec.Body.String())
+	assert.Nil(t, repo.products[product.ID].DeletedAt)
+}
+
+func TestHandler_Restore_Errors(t *testing.T) {
+	product := laptop()
+	target := "/products/" + product.ID.Hex() + ":restore"
+	cases := []struct {
+		name   string
+		method string
+		target string
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodDelete, target, nil, http.StatusMethodNotAllowed},
+		{"id invalido", http.MethodPost, "/products/abc:restore", nil, http.StatusBadRequest},
+		{"no eliminado", http.MethodPost, target, nil, http.StatusNotFound},
+		{"error de base de datos", http.MethodPost, target, errors.New("error de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo := newFakeRepository(product)
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, c.target, "", nil)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+		})
+	}
+}
+
+func TestHandler_DeleteMany(t *testing.T) {
+	// Arrange
+	product := laptop()
+	repo := newFakeRepository(product)
+	body := `{"ids":["` + product.ID.Hex() + `","` + primitive.NewObjectID().Hex() + `"]}`
+
+	// Act
+	rec := serve(repo, http.MethodDelete, "/products:bulk?ordered=false", body, nil)
+
+	// Assert - Regla de negocio: Un lote con fallos responde 207 y elimina los ítems válidos
+	require.Equal(t, http.StatusMultiStatus, rec.Code)
+	var res bulk.Result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, 1, res.Succeeded)
+	assert.Equal(t, 1, res.Failed)
+	assert.NotNil(t, repo.products[product.ID].DeletedAt)
+}
+
+func TestHandler_DeleteMany_AlreadyDeleted(t *testing.T) {
+	// Arrange
+	product := laptop()
+	repo := newFakeRepository(product)
+	body := `{"ids":["` + product.ID.Hex() + `","` + product.ID.Hex() + `"]}`
+
+	// Act
+	rec := serve(repo, http.MethodDelete, "/products:bulk?ordered=false", body, nil)
+
+	// Assert - Regla de negocio: Un producto eliminado por otra escritura no se reporta como eliminado de nuevo
+	require.Equal(t, http.StatusMultiStatus, rec.Code)
+	var res bulk.Result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, bulk.StatusSucceeded, res.Items[0].Status)
+	assert.Equal(t, bulk.StatusFailed, res.Items[1].Status)
+	assert.Equal(t, product.Version+1, repo.products[product.ID].Version)
+}
+
+func TestHandler_DeleteMany_Errors(t *testing.T) {
+	product := laptop()
+	tooMany := `{"ids":[` + strings.Repeat(`"x",`, bulk.MaxItems) + `"x"]}`
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodPost, "/products:bulk", `{"ids":[]}`, nil, http.StatusMethodNotAllowed},
+		{"ordered invalido", http.MethodDelete, "/products:bulk?ordered=maybe", `{"ids":[]}`, nil, http.StatusBadRequest},
+		{"json invalido", http.MethodDelete, "/products:bulk", `[]`, nil, http.StatusBadRequest},
+		{"demasiados items", http.MethodDelete, "/products:bulk", tooMany, nil, http.StatusRequestEntityTooLarge},
+		{"error de base de datos", http.MethodDelete, "/products:bulk", `{"ids":["` + product.ID.Hex() + `"]}`, errors.New("error de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo := newFakeRepository(product)
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, c.target, c.body, nil)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+			assert.Nil(t, repo.products[product.ID].DeletedAt)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InstrumentedRepository records the duration and errors of every call to
+// the repository it wraps. Deleting a product is a soft delete, so its
+// operation is reported as SoftDeleteByID.
+type InstrumentedRepository struct {
+	next    ProductRepositoryInterface
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedRepository(next ProductRepositoryInterface, m *metrics.Metrics) *InstrumentedRepository {
+	return &InstrumentedRepository{next: next, metrics: m}
+}
+
+func (r *InstrumentedRepository) SoftDeleteByID(ctx context.Context, id any, versions []int64, at time.Time) (_ *model.Product, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "SoftDeleteByID", time.Now(), &err)
+	return r.next.SoftDeleteByID(ctx, id, versions, at)
+}
+
+func (r *InstrumentedRepository) ExistingIDs(ctx context.Context, ids []primitive.ObjectID) (existing map[primitive.ObjectID]bool, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "ExistingIDs", time.Now(), &err)
+	return r.next.ExistingIDs(ctx, ids)
+}
+
+func (r *InstrumentedRepository) RestoreByID(ctx context.Context, id any) (_ *model.Product, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "RestoreByID", time.Now(), &err)
+	return r.next.RestoreByID(ctx, id)
+}
+
+func (r *InstrumentedRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (_ []model.Product, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "PurgeDeletedBefore", time.Now(), &err)
+	return r.next.PurgeDeletedBefore(ctx, before)
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRepository deletes products held in a memstore.Store with the
+// same matching rules as DeleteRepository.
+type MemoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) *MemoryRepository {
+	return &MemoryRepository{store: store}
+}
+
+func (r *MemoryRepository) SoftDeleteByID(ctx context.Context, id any, versions []int64, at time.Time) (*model.Product, error) {
+	return r.update(id, func(p *model.Product) bool {
+		if p.DeletedAt != nil || (versions != nil && !slices.Contains(versions, p.Version)) {
+			return false
+		}
+		markDeleted(p, at)
+		return true
+	})
+}
+
+func (r *MemoryRepository) ExistingIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	existing := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		if p, ok := r.store.Get(id); ok && p.DeletedAt == nil {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
+
+func (r *MemoryRepository) RestoreByID(ctx context.Context, id any) (*model.Product, error) {
+	return r.update(id, func(p *model.Product) bool {
+		if p.DeletedAt == nil {
+			return false
+		}
+		p.DeletedAt = nil
+		p.Version++
+		return true
+	})
+}
+
+func (r *MemoryRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]model.Product, error) {
+	purged := []model.Product{}
+	r.store.DeleteWhere(func(p model.Product) bool {
+		if p.DeletedAt == nil || !p.DeletedAt.Before(before) {
+			return false
+		}
+		purged = append(purged, p)
+		return true
+	})
+	return purged, nil
+}
+
+func markDeleted(p *model.Product, at time.Time) {
+	p.DeletedAt = &at
+	p.Version++
+}
+
+// update applies fn to the product with the given id and returns the
+// product as it was before, or mongo.ErrNoDocuments when fn wrote nothing.
+func (r *MemoryRepository) update(id any, fn func(*model.Product) bool) (*model.Product, error) {
+	oid, _ := id.(primitive.ObjectID)
+	var before model.Product
+	written := r.store.Update(oid, func(p *model.Product) bool {
+		before = *p
+		return fn(p)
+	})
+	if !written {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &before, nil
+}
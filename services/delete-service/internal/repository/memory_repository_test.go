@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMemoryRepository_SoftDeleteAndRestore(t *testing.T) {
+	// Arrange
+	store := memstore.New()
+	product := model.Product{ID: primitive.NewObjectID(), Version: 1}
+	require.NoError(t, store.Insert(product))
+	repo := NewMemoryRepository(store)
+	ctx := context.Background()
+	at := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+
+	// Act
+	_, staleErr := repo.SoftDeleteByID(ctx, product.ID, []int64{7}, at)
+	deleted, deleteErr := repo.SoftDeleteByID(ctx, product.ID, []int64{1}, at)
+	_, againErr := repo.SoftDeleteByID(ctx, product.ID, nil, at)
+	restored, restoreErr := repo.RestoreByID(ctx, product.ID)
+
+	// Assert - Regla de negocio: Borrar y restaurar solo aplican en el estado correcto
+	assert.ErrorIs(t, staleErr, mongo.ErrNoDocuments, "Una versión obsoleta no elimina")
+	require.NoError(t, deleteErr)
+	assert.Nil(t, deleted.DeletedAt, "Se devuelve el producto previo al borrado")
+	assert.Equal(t, int64(1), deleted.Version)
+	assert.ErrorIs(t, againErr, mongo.ErrNoDocuments, "Un producto eliminado no se elimina de nuevo")
+	require.NoError(t, restoreErr)
+	assert.Equal(t, at, *restored.DeletedAt)
+	saved, _ := store.Get(product.ID)
+	assert.Nil(t, saved.DeletedAt)
+	assert.Equal(t, int64(3), saved.Version)
+}
+
+func TestMemoryRepository_PurgeDeletedBefore(t *testing.T) {
+	// Arrange
+	store := memstore.New()
+	old := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	recent := time.Date(2025, 11, 19, 0, 0, 0, 0, time.UTC)
+	expired := model.Product{ID: primitive.NewObjectID(), DeletedAt: &old, Version: 3}
+	require.NoError(t, store.Insert(expired))
+	for _, deletedAt := range []*time.Time{&recent, nil} {
+		require.NoError(t, store.Insert(model.Product{ID: primitive.NewObjectID(), DeletedAt: deletedAt}))
+	}
+	repo := NewMemoryRepository(store)
+
+	// Act
+	purged, err := repo.PurgeDeletedBefore(context.Background(), time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC))
+
+	// Assert - Regla de negocio: Solo se purgan los eliminados antes del corte
+	require.NoError(t, err)
+	assert.Equal(t, []model.Product{expired}, purged)
+	assert.Len(t, store.All(), 2)
+}
@@ -2,14 +2,21 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/model"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ProductRepositoryInterface interface {
-	DeleteByID(ctx context.Context, id any) (*mongo.DeleteResult, error)
+	SoftDeleteByID(ctx context.Context, id any, versions []int64, at time.Time) (*model.Product, error)
+	ExistingIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]bool, error)
+	RestoreByID(ctx context.Context, id any) (*model.Product, error)
+	PurgeDeletedBefore(ctx context.Context, before time.Time) ([]model.Product, error)
 }
 
 type DeleteRepository struct {
@@ -20,9 +27,94 @@ func NewDeleteRepository(db *mongo.Database) *DeleteRepository {
 	return &DeleteRepository{collection: db.Collection("products")}
 }
 
-func (r *DeleteRepository) DeleteByID(ctx context.Context, id any) (*mongo.DeleteResult, error) {
+// SoftDeleteByID marks the product as deleted at the given time. Like any
+// other write it bumps the version, and when versions is not nil it only
+// matches the product while it is at one of them. It returns the product as
+// it was before the deletion, or mongo.ErrNoDocuments when nothing matched.
+func (r *DeleteRepository) SoftDeleteByID(ctx context.Context, id any, versions []int64, at time.Time) (*model.Product, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
-	return res, err
+	filter := bson.M{"_id": id, model.DeletedAtField: nil}
+	if versions != nil {
+		filter["version"] = model.VersionFilter(versions...)
+	}
+	return r.findOneAndUpdate(ctx, filter, softDelete(at))
+}
+
+// ExistingIDs reports which of ids belong to products that exist and are
+// not soft-deleted.
+func (r *DeleteRepository) ExistingIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, model.DeletedAtField: nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	existing := make(map[primitive.ObjectID]bool, len(docs))
+	for _, d := range docs {
+		existing[d.ID] = true
+	}
+	return existing, nil
+}
+
+// RestoreByID clears the deletion mark of a soft-deleted product and returns
+// the product as it was before the restore.
+func (r *DeleteRepository) RestoreByID(ctx context.Context, id any) (*model.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	filter := bson.M{"_id": id, model.DeletedAtField: bson.M{"$ne": nil}}
+	update := bson.M{"$unset": bson.M{model.DeletedAtField: ""}, "$inc": bson.M{"version": 1}}
+	return r.findOneAndUpdate(ctx, filter, update)
+}
+
+// PurgeDeletedBefore hard-deletes every product soft-deleted before the
+// given time and returns them as they were when deleted. Each product is
+// deleted only if it is still expired, so one restored meanwhile is kept.
+func (r *DeleteRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]model.Product, error) {
+	filter := bson.M{model.DeletedAtField: bson.M{"$lt": before}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	purged := []model.Product{}
+	for _, d := range docs {
+		filter["_id"] = d.ID
+		var p model.Product
+		err := r.collection.FindOneAndDelete(ctx, filter).Decode(&p)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+		purged = append(purged, p)
+	}
+	return purged, nil
+}
+
+func (r *DeleteRepository) findOneAndUpdate(ctx context.Context, filter, update bson.M) (*model.Product, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	var before model.Product
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&before); err != nil {
+		return nil, err
+	}
+	return &before, nil
+}
+
+func softDelete(at time.Time) bson.M {
+	return bson.M{"$set": bson.M{model.DeletedAtField: at}, "$inc": bson.M{"version": 1}}
 }
@@ -2,19 +2,184 @@ package service
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
 	"github.com/blandoncj/go-products-api/services/delete-service/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrVersionMismatch = errors.New("product version does not match")
+)
+
+// DefaultRetention is how long soft-deleted products are kept before the
+// purge removes them for good.
+const DefaultRetention = 30 * 24 * time.Hour
+
 type ProductService struct {
 	repo repository.ProductRepositoryInterface
+
+	// Retention is how long a soft-deleted product can still be restored.
+	Retention time.Duration
+	// Audit records every delete and restore; nil records nothing.
+	Audit *audit.Recorder
+
+	now func() time.Time
 }
 
 func NewProductService(repo repository.ProductRepositoryInterface) *ProductService {
-	return &ProductService{repo: repo}
+	return &ProductService{repo: repo, Retention: DefaultRetention, now: time.Now}
+}
+
+// DeleteProduct soft-deletes the product if it exists and, when ifMatch is
+// not nil, is at one of the listed versions.
+func (s *ProductService) DeleteProduct(ctx context.Context, id primitive.ObjectID, ifMatch []int64) (err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.DeleteProduct")
+	defer tracing.End(span, &err)
+
+	at := s.now().UTC()
+	before, err := s.repo.SoftDeleteByID(ctx, id, ifMatch, at)
+	if err == nil {
+		return s.Audit.Record(ctx, deletion(before, at))
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	if ifMatch == nil {
+		return ErrProductNotFound
+	}
+	existing, err := s.repo.ExistingIDs(ctx, []primitive.ObjectID{id})
+	if err != nil {
+		return err
+	}
+	if existing[id] {
+		return ErrVersionMismatch
+	}
+	return ErrProductNotFound
+}
+
+// RestoreProduct undoes a soft delete. Products that were never deleted,
+// or were already purged, are reported as not found.
+func (s *ProductService) RestoreProduct(ctx context.Context, id primitive.ObjectID) (err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.RestoreProduct")
+	defer tracing.End(span, &err)
+
+	before, err := s.repo.RestoreByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	after := *before
+	after.DeletedAt = nil
+	after.Version++
+	return s.Audit.Record(ctx, audit.Changed(audit.ActionRestore, before, &after))
+}
+
+// Purge hard-deletes the products that were soft-deleted longer than
+// Retention ago, records a purge by the system actor for each, and returns
+// how many were removed.
+func (s *ProductService) Purge(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.Purge")
+	defer tracing.End(span, &err)
+
+	purged, err := s.repo.PurgeDeletedBefore(ctx, s.now().UTC().Add(-s.Retention))
+	mutations := make([]audit.Mutation, len(purged))
+	for i, p := range purged {
+		mutations[i] = audit.Mutation{
+			Action:    audit.ActionPurge,
+			ProductID: p.ID,
+			Version:   p.Version,
+			Changes:   []audit.Change{},
+			Actor:     audit.System,
+		}
+	}
+	if err := errors.Join(err, s.Audit.Record(ctx, mutations...)); err != nil {
+		return 0, err
+	}
+	return int64(len(purged)), nil
+}
+
+// RunPurge calls Purge every interval until ctx is done.
+func (s *ProductService) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.Purge(ctx)
+			if err != nil {
+				logging.FromContext(ctx).Error("purge failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				logging.FromContext(ctx).Info("purged deleted products", "count", n)
+			}
+		}
+	}
+}
+
+// DeleteMany soft-deletes the listed products one at a time, each only
+// while it is still live, so a product deleted concurrently is reported as
+// not found rather than deleted twice. IDs that are malformed or unknown
+// fail; in ordered mode they also stop the batch, so only the products
+// listed before them are deleted.
+func (s *ProductService) DeleteMany(ctx context.Context, ids []string, ordered bool) (_ *bulk.Result, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.DeleteMany")
+	defer tracing.End(span, &err)
+
+	if len(ids) > bulk.MaxItems {
+		return nil, bulk.ErrTooManyItems
+	}
+	res := bulk.NewResult(len(ids), ordered)
+
+	at := s.now().UTC()
+	var mutations []audit.Mutation
+	for i, idHex := range ids {
+		res.SetID(i, idHex)
+		objID, err := primitive.ObjectIDFromHex(idHex)
+		if err != nil {
+			res.Fail(i, errors.New("invalid id format"))
+			if ordered {
+				break
+			}
+			continue
+		}
+		before, err := s.repo.SoftDeleteByID(ctx, objID, nil, at)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			res.Fail(i, ErrProductNotFound)
+			if ordered {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			return nil, errors.Join(err, s.Audit.Record(ctx, mutations...))
+		}
+		res.Succeed(i)
+		mutations = append(mutations, deletion(before, at))
+	}
+	if err := s.Audit.Record(ctx, mutations...); err != nil {
+		return nil, err
+	}
+	return res.Finish(), nil
 }
 
-func (s *ProductService) DeleteProduct(ctx context.Context, id interface{}) error {
-	_, err := s.repo.DeleteByID(ctx, id)
-	return err
+// deletion describes the soft delete at at of the product before.
+func deletion(before *model.Product, at time.Time) audit.Mutation {
+	after := *before
+	after.DeletedAt = &at
+	after.Version++
+	return audit.Changed(audit.ActionDelete, before, &after)
 }
@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,12 +19,36 @@ type MockDeleteRepository struct {
 	mock.Mock
 }
 
-func (m *MockDeleteRepository) DeleteByID(ctx context.Context, id any) (*mongo.DeleteResult, error) {
+func (m *MockDeleteRepository) SoftDeleteByID(ctx context.Context, id any, versions []int64, at time.Time) (*model.Product, error) {
+	args := m.Called(ctx, id, versions, at)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockDeleteRepository) ExistingIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[primitive.ObjectID]bool), args.Error(1)
+}
+
+func (m *MockDeleteRepository) RestoreByID(ctx context.Context, id any) (*model.Product, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*mongo.DeleteResult), args.Error(1)
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockDeleteRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) ([]model.Product, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Product), args.Error(1)
 }
 
 func TestProductService_DeleteProduct_Success(t *testing.T) {
@@ -30,10 +58,10 @@ func TestProductService_DeleteProduct_Success(t *testing.T) {
 	ctx := context.Background()
 	productID := primitive.NewObjectID()
 
-	mockRepo.On("DeleteByID", ctx, productID).Return(&mongo.DeleteResult{DeletedCount: 1}, nil)
+	mockRepo.On("SoftDeleteByID", mock.Anything, productID, []int64(nil), mock.Anything).Return(&model.Product{ID: productID}, nil)
 
 	// Act
-	err := service.DeleteProduct(ctx, productID)
+	err := service.DeleteProduct(ctx, productID, nil)
 
 	// Assert - Regla de negocio: Producto existente debe eliminarse correctamente
 	assert.NoError(t, err, "El producto debe eliminarse sin errores")
@@ -47,13 +75,13 @@ func TestProductService_DeleteProduct_NotFound(t *testing.T) {
 	ctx := context.Background()
 	productID := primitive.NewObjectID()
 
-	mockRepo.On("DeleteByID", ctx, productID).Return(&mongo.DeleteResult{DeletedCount: 0}, nil)
+	mockRepo.On("SoftDeleteByID", mock.Anything, productID, []int64(nil), mock.Anything).Return(nil, mongo.ErrNoDocuments)
 
 	// Act
-	err := service.DeleteProduct(ctx, productID)
+	err := service.DeleteProduct(ctx, productID, nil)
 
-	// Assert - Regla de negocio: Eliminar producto inexistente no genera error (idempotencia)
-	assert.NoError(t, err, "No debe generar error si el producto no existe")
+	// Assert - Regla de negocio: Eliminar producto inexistente debe reportarse como no encontrado
+	assert.ErrorIs(t, err, ErrProductNotFound, "Debe reportar que el producto no existe")
 	mockRepo.AssertExpectations(t)
 }
 
@@ -64,13 +92,271 @@ func TestProductService_DeleteProduct_DatabaseError(t *testing.T) {
 	ctx := context.Background()
 	productID := primitive.NewObjectID()
 
-	mockRepo.On("DeleteByID", ctx, productID).Return(nil, errors.New("error de conexión"))
+	mockRepo.On("SoftDeleteByID", mock.Anything, productID, []int64(nil), mock.Anything).Return(nil, errors.New("error de conexión"))
 
 	// Act
-	err := service.DeleteProduct(ctx, productID)
+	err := service.DeleteProduct(ctx, productID, nil)
 
 	// Assert - Regla de negocio: Errores de BD deben propagarse
 	assert.Error(t, err, "Debe retornar error cuando hay problema de conexión")
 	assert.Contains(t, err.Error(), "conexión")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestProductService_DeleteProduct_IfMatch(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("SoftDeleteByID", mock.Anything, productID, []int64{3}, mock.Anything).Return(&model.Product{ID: productID}, nil)
+
+	// Act
+	err := service.DeleteProduct(ctx, productID, []int64{3})
+
+	// Assert - Regla de negocio: Con la versión vigente el producto se elimina
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_DeleteProduct_StaleIfMatch(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("SoftDeleteByID", mock.Anything, productID, []int64{2}, mock.Anything).Return(nil, mongo.ErrNoDocuments)
+	mockRepo.On("ExistingIDs", mock.Anything, []primitive.ObjectID{productID}).Return(map[primitive.ObjectID]bool{productID: true}, nil)
+
+	// Act
+	err := service.DeleteProduct(ctx, productID, []int64{2})
+
+	// Assert - Regla de negocio: Eliminar con una versión obsoleta se rechaza
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_DeleteMany_UnorderedPartialFailure(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	a, missing, b := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	ids := []string{a.Hex(), "bad-id", missing.Hex(), b.Hex()}
+
+	mockRepo.On("SoftDeleteByID", mock.Anything, a, []int64(nil), mock.Anything).Return(&model.Product{ID: a}, nil)
+	mockRepo.On("SoftDeleteByID", mock.Anything, missing, []int64(nil), mock.Anything).Return(nil, mongo.ErrNoDocuments)
+	mockRepo.On("SoftDeleteByID", mock.Anything, b, []int64(nil), mock.Anything).Return(&model.Product{ID: b}, nil)
+
+	// Act
+	res, err := service.DeleteMany(ctx, ids, false)
+
+	// Assert - Regla de negocio: Cada ID reporta si fue eliminado o por qué falló
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bulk.StatusSucceeded, bulk.StatusFailed, bulk.StatusFailed, bulk.StatusSucceeded}, statuses(res))
+	assert.Equal(t, "invalid id format", res.Items[1].Error)
+	assert.Equal(t, ErrProductNotFound.Error(), res.Items[2].Error)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_DeleteMany_OrderedStopsAtFirstFailure(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	a, missing, b := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	mockRepo.On("SoftDeleteByID", mock.Anything, a, []int64(nil), mock.Anything).Return(&model.Product{ID: a}, nil)
+	mockRepo.On("SoftDeleteByID", mock.Anything, missing, []int64(nil), mock.Anything).Return(nil, mongo.ErrNoDocuments)
+
+	// Act
+	res, err := service.DeleteMany(ctx, []string{a.Hex(), missing.Hex(), b.Hex()}, true)
+
+	// Assert - Regla de negocio: En modo ordenado nada después del primer fallo se elimina
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bulk.StatusSucceeded, bulk.StatusFailed, bulk.StatusSkipped}, statuses(res))
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "SoftDeleteByID", mock.Anything, b, mock.Anything, mock.Anything)
+}
+
+func TestProductService_DeleteMany_DatabaseError(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	id := primitive.NewObjectID()
+
+	mockRepo.On("SoftDeleteByID", mock.Anything, id, []int64(nil), mock.Anything).Return(nil, errors.New("error de conexión"))
+
+	// Act
+	res, err := service.DeleteMany(ctx, []string{id.Hex()}, true)
+
+	// Assert - Regla de negocio: Errores de BD deben propagarse
+	assert.Error(t, err)
+	assert.Nil(t, res)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_DeleteProduct_MarksDeletionTime(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	service := NewProductService(mockRepo)
+	now := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("SoftDeleteByID", mock.Anything, productID, []int64(nil), now).Return(&model.Product{ID: productID}, nil)
+
+	// Act
+	err := service.DeleteProduct(ctx, productID, nil)
+
+	// Assert - Regla de negocio: Eliminar solo marca el producto con la fecha de borrado
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_RestoreProduct_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("RestoreByID", mock.Anything, productID).Return(&model.Product{ID: productID}, nil)
+
+	// Act
+	err := service.RestoreProduct(ctx, productID)
+
+	// Assert - Regla de negocio: Un producto eliminado puede restaurarse
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_RestoreProduct_NotDeleted(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("RestoreByID", mock.Anything, productID).Return(nil, mongo.ErrNoDocuments)
+
+	// Act
+	err := service.RestoreProduct(ctx, productID)
+
+	// Assert - Regla de negocio: Solo se restauran productos eliminados y aún no purgados
+	assert.ErrorIs(t, err, ErrProductNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_DeleteAndRestore_RecordAudit(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	store := audit.NewMemoryStore()
+	service := NewProductService(mockRepo)
+	service.Audit = audit.NewRecorder(store)
+	now := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("SoftDeleteByID", mock.Anything, productID, []int64(nil), now).Return(&model.Product{ID: productID, Version: 2}, nil)
+	mockRepo.On("RestoreByID", mock.Anything, productID).Return(&model.Product{ID: productID, Version: 3, DeletedAt: &now}, nil)
+
+	// Act
+	deleteErr := service.DeleteProduct(ctx, productID, nil)
+	restoreErr := service.RestoreProduct(ctx, productID)
+
+	// Assert - Regla de negocio: Borrar y restaurar quedan registrados con la fecha de borrado
+	assert.NoError(t, deleteErr)
+	assert.NoError(t, restoreErr)
+	entries, _ := store.History(ctx, productID, primitive.NilObjectID, 10)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, audit.ActionRestore, entries[0].Action)
+		assert.Equal(t, int64(4), entries[0].Version)
+		assert.Equal(t, []audit.Change{{Field: "deleted_at", Before: now}}, entries[0].Changes)
+		assert.Equal(t, audit.ActionDelete, entries[1].Action)
+		assert.Equal(t, int64(3), entries[1].Version)
+		assert.Equal(t, []audit.Change{{Field: "deleted_at", After: now}}, entries[1].Changes)
+	}
+}
+
+func TestProductService_Purge_UsesRetention(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	service := NewProductService(mockRepo)
+	now := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	service.Retention = 7 * 24 * time.Hour
+	ctx := context.Background()
+
+	mockRepo.On("PurgeDeletedBefore", mock.Anything, now.Add(-7*24*time.Hour)).Return(make([]model.Product, 3), nil)
+
+	// Act
+	n, err := service.Purge(ctx)
+
+	// Assert - Regla de negocio: Se purgan los productos eliminados hace más que la retención
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_Purge_RecordsAudit(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	store := audit.NewMemoryStore()
+	service := NewProductService(mockRepo)
+	service.Audit = audit.NewRecorder(store)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("PurgeDeletedBefore", mock.Anything, mock.Anything).Return([]model.Product{{ID: productID, Version: 4}}, nil)
+
+	// Act
+	n, err := service.Purge(ctx)
+
+	// Assert - Regla de negocio: Cada producto purgado queda registrado por el sistema con su última versión
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	entries, _ := store.History(ctx, productID, primitive.NilObjectID, 10)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, audit.ActionPurge, entries[0].Action)
+		assert.Equal(t, audit.System, entries[0].Actor)
+		assert.Equal(t, int64(4), entries[0].Version)
+	}
+}
+
+func TestProductService_DeleteMany_RecordsVersion(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockDeleteRepository)
+	store := audit.NewMemoryStore()
+	service := NewProductService(mockRepo)
+	service.Audit = audit.NewRecorder(store)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("SoftDeleteByID", mock.Anything, productID, []int64(nil), mock.Anything).Return(&model.Product{ID: productID, Version: 2}, nil)
+
+	// Act
+	_, err := service.DeleteMany(ctx, []string{productID.Hex()}, true)
+
+	// Assert - Regla de negocio: El borrado masivo registra la versión resultante de cada producto
+	assert.NoError(t, err)
+	entries, _ := store.History(ctx, productID, primitive.NilObjectID, 10)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, audit.ActionDelete, entries[0].Action)
+		assert.Equal(t, int64(3), entries[0].Version)
+	}
+}
+
+func statuses(res *bulk.Result) []string {
+	var out []string
+	for _, item := range res.Items {
+		out = append(out, item.Status)
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/blandoncj/go-products-api/pkg/health"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/ratelimit"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
+	"github.com/blandoncj/go-products-api/services/gateway-service/internal/controller"
+	"github.com/blandoncj/go-products-api/services/gateway-service/internal/service"
+)
+
+func main() {
+	port := os.Getenv("GATEWAY_SERVICE_PORT")
+	if port == "" {
+		port = "8080"
+	}
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := health.Probe(context.Background(), "http://127.0.0.1:"+port+"/readyz"); err != nil {
+			log.Fatalf("gateway service: %v", err)
+		}
+		return
+	}
+
+	logger := logging.New("gateway-service")
+	slog.SetDefault(logger)
+
+	cfg, err := server.LoadConfig(fmt.Sprintf(":%s", port))
+	if err != nil {
+		log.Fatalf("gateway service: %v", err)
+	}
+
+	backends, err := service.BackendsFromEnv()
+	if err != nil {
+		log.Fatalf("gateway service: %v", err)
+	}
+	closeTracing, err := tracing.Setup(context.Background(), "gateway-service")
+	if err != nil {
+		log.Fatalf("gateway service: %v", err)
+	}
+	limiter, err := ratelimit.FromEnv()
+	if err != nil {
+		log.Fatalf("gateway service: %v", err)
+	}
+	m := metrics.New("gateway-service")
+	handler := http.NewServeMux()
+	health.NewChecker("gateway-service").Register(handler)
+	handler.Handle("/metrics", m.Handler())
+	handler.Handle("/", limiter.Middleware(controller.NewHandler(backends)))
+	log.Printf("Gateway service listening on %s", cfg.Addr)
+	root := tracing.Middleware(logging.Middleware(logger)(m.Middleware(handler)))
+	if err := server.Run(context.Background(), cfg, root, closeTracing); err != nil {
+		log.Fatalf("gateway service: %v", err)
+	}
+	log.Printf("Gateway service stopped")
+}
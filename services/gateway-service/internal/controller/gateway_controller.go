@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
+	"github.com/blandoncj/go-products-api/services/gateway-service/internal/service"
+)
+
+func NewHandler(backends []service.Backend) http.Handler {
+	proxies := make(map[string]*httputil.ReverseProxy, len(backends))
+	for _, b := range backends {
+		proxies[b.Name] = newProxy(b)
+	}
+	health := &service.HealthChecker{Backends: backends, Client: &http.Client{Timeout: 2 * time.Second, Transport: tracing.Transport(http.DefaultTransport)}}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+		report := health.Check(ctx)
+		status := http.StatusOK
+		if !report.Healthy() {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		backend, allowed, err := service.Route(r.Method, r.URL.Path)
+		if errors.Is(err, service.ErrMethodNotAllowed) {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, http.StatusMethodNotAllowed, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		proxies[backend].ServeHTTP(w, r)
+	})
+
+	return mux
+}
+
+func newProxy(b service.Backend) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(b.URL)
+	proxy.Transport = tracing.Transport(http.DefaultTransport)
+	proxy.ModifyResponse = func(res *http.Response) error {
+		// The gateway already set the request ID it forwarded.
+		res.Header.Del(logging.RequestIDHeader)
+		return nil
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		logging.FromContext(r.Context()).Error("backend unavailable", "backend", b.Name, "error", err)
+		writeError(w, http.StatusBadGateway, b.Name+" service unavailable")
+	}
+	return proxy
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
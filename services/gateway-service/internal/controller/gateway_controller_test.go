@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/services/gateway-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackends starts one server per service that answers with its own
+// name, so tests can see where a request was sent.
+func fakeBackends(t *testing.T, down ...string) []service.Backend {
+	var backends []service.Backend
+	for _, name := range []string{service.Create, service.Read, service.Update, service.Delete} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, d := range down {
+				if d == name {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+			w.Header().Set("X-Backend", name)
+			_, _ = w.Write([]byte(r.Method + " " + r.URL.RequestURI()))
+		}))
+		t.Cleanup(srv.Close)
+		u, _ := url.Parse(srv.URL)
+		backends = append(backends, service.Backend{Name: name, URL: u})
+	}
+	return backends
+}
+
+func serve(handler http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestHandler_ForwardsToBackend(t *testing.T) {
+	// Arrange
+	handler := NewHandler(fakeBackends(t))
+
+	// Act
+	rec := serve(handler, http.MethodPatch, "/products/507f1f77bcf86cd799439011?x=1")
+
+	// Assert - Regla de negocio: La petición llega intacta al servicio que la atiende
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, service.Update, rec.Header().Get("X-Backend"))
+	assert.Equal(t, "PATCH /products/507f1f77bcf86cd799439011?x=1", rec.Body.String())
+}
+
+func TestHandler_RouteErrors(t *testing.T) {
+	// Arrange
+	handler := NewHandler(fakeBackends(t))
+
+	// Act
+	notFound := serve(handler, http.MethodGet, "/orders")
+	notAllowed := serve(handler, http.MethodPut, "/products")
+
+	// Assert - Regla de negocio: El gateway solo reenvía rutas y métodos conocidos
+	assert.Equal(t, http.StatusNotFound, notFound.Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, notAllowed.Code)
+	assert.Equal(t, "GET, POST", notAllowed.Header().Get("Allow"))
+}
+
+func TestHandler_BackendUnavailable(t *testing.T) {
+	// Arrange
+	unreachable, _ := url.Parse("http://127.0.0.1:1")
+	handler := NewHandler([]service.Backend{{Name: service.Read, URL: unreachable}})
+
+	// Act
+	rec := serve(handler, http.MethodGet, "/products")
+
+	// Assert - Regla de negocio: Un servicio caído responde 502
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.JSONEq(t, `{"error":"read service unavailable"}`, rec.Body.String())
+}
+
+func TestHandler_Health(t *testing.T) {
+	// Act
+	up := serve(NewHandler(fakeBackends(t)), http.MethodGet, "/health")
+	degraded := serve(NewHandler(fakeBackends(t, service.Delete)), http.MethodGet, "/health")
+
+	// Assert - Regla de negocio: El estado del gateway agrega el de los servicios
+	assert.Equal(t, http.StatusOK, up.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, degraded.Code)
+	assert.Contains(t, degraded.Body.String(), `"delete":{"status":"down"`)
+}
+
+func TestHandler_PropagatesRequestID(t *testing.T) {
+	// Arrange
+	var forwarded string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(logging.RequestIDHeader)
+		w.Header().Set(logging.RequestIDHeader, forwarded)
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	handler := logging.Middleware(slog.New(slog.NewTextHandler(io.Discard, nil)))(
+		NewHandler([]service.Backend{{Name: service.Read, URL: u}}))
+	req := httptest.NewRequest(http.MethodGet, "/products", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.ServeHTTP(rec, req)
+
+	// Assert - Regla de negocio: El servicio de destino recibe el mismo ID de la petición
+	assert.Equal(t, "req-42", forwarded)
+	assert.Equal(t, []string{"req-42"}, rec.Header().Values(logging.RequestIDHeader))
+}
@@ -0,0 +1,102 @@
+//go:build integration
+
+package controller
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/health"
+	"github.com/blandoncj/go-products-api/services/gateway-service/internal/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jwtSecret = "integration-secret"
+
+// startService builds a service binary and runs it in memory mode on
+// storeFile, returning its backend once it is ready.
+func startService(t *testing.T, name, portEnv, storeFile string) service.Backend {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), name)
+	build := exec.Command("go", "build", "-o", bin, "./cmd")
+	build.Dir = filepath.Join("..", "..", "..", name+"-service")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+	require.NoError(t, l.Close())
+
+	cmd := exec.Command(bin)
+	cmd.Env = append(os.Environ(),
+		"STORAGE_BACKEND=memory",
+		"MEMORY_STORE_FILE="+storeFile,
+		auth.SecretEnv+"="+jwtSecret,
+		portEnv+"="+port,
+	)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	u, _ := url.Parse("http://" + net.JoinHostPort("127.0.0.1", port))
+	require.Eventually(t, func() bool {
+		return health.Probe(context.Background(), u.String()+"/readyz") == nil
+	}, 30*time.Second, 50*time.Millisecond, "%s service did not start", name)
+	return service.Backend{Name: name, URL: u}
+}
+
+func TestHandler_SharedMemoryStore(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	// Arrange
+	storeFile := filepath.Join(t.TempDir(), "store.bson")
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		Roles:            []string{auth.RoleAdmin},
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "ana", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+	}).SignedString([]byte(jwtSecret))
+	require.NoError(t, err)
+	handler := NewHandler([]service.Backend{
+		startService(t, service.Create, "CREATE_SERVICE_PORT", storeFile),
+		startService(t, service.Read, "READ_SERVICE_PORT", storeFile),
+	})
+	create := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{"name":"Laptop","price":1500,"stock":10}`))
+	create.Header.Set("Content-Type", "application/json")
+	create.Header.Set("Authorization", "Bearer "+token)
+
+	// Act
+	created := httptest.NewRecorder()
+	handler.ServeHTTP(created, create)
+	location := created.Header().Get("Location")
+	read := serve(handler, http.MethodGet, location)
+	historyReq := httptest.NewRequest(http.MethodGet, location+"/history", nil)
+	historyReq.Header.Set("Authorization", "Bearer "+token)
+	history := httptest.NewRecorder()
+	handler.ServeHTTP(history, historyReq)
+	anonymous := serve(handler, http.MethodGet, location+"/history")
+
+	// Assert - Regla de negocio: En modo memoria un producto creado en un servicio se lee desde otro
+	require.Equal(t, http.StatusCreated, created.Code, created.Body.String())
+	require.Equal(t, http.StatusOK, read.Code, read.Body.String())
+	assert.Contains(t, read.Body.String(), `"name":"Laptop"`)
+	require.Equal(t, http.StatusOK, history.Code, history.Body.String())
+	assert.Contains(t, history.Body.String(), `"action":"create"`)
+	assert.Equal(t, http.StatusUnauthorized, anonymous.Code, "El historial solo lo leen los administradores")
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// backendEnv lists, for every backend, the variable holding its base URL
+// and the address it has in docker-compose.
+var backendEnv = []struct {
+	name, env, def string
+}{
+	{Create, "CREATE_SERVICE_URL", "http://create:8081"},
+	{Read, "READ_SERVICE_URL", "http://read:8082"},
+	{Update, "UPDATE_SERVICE_URL", "http://update:8083"},
+	{Delete, "DELETE_SERVICE_URL", "http://delete:8084"},
+}
+
+// BackendsFromEnv returns the four backends, reading their base URLs from
+// the environment.
+func BackendsFromEnv() ([]Backend, error) {
+	var backends []Backend
+	for _, b := range backendEnv {
+		raw := os.Getenv(b.env)
+		if raw == "" {
+			raw = b.def
+		}
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s: %q", b.env, raw)
+		}
+		backends = append(backends, Backend{Name: b.name, URL: u})
+	}
+	return backends, nil
+}
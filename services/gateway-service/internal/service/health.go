@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+)
+
+type Backend struct {
+	Name string
+	URL  *url.URL
+}
+
+type BackendHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type HealthReport struct {
+	Status   string                   `json:"status"`
+	Services map[string]BackendHealth `json:"services"`
+}
+
+func (r HealthReport) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+type HealthChecker struct {
+	Backends []Backend
+	Client   *http.Client
+}
+
+// Check calls the /readyz endpoint of every backend concurrently. The
+// report is ok only when all of them answer 200.
+func (h *HealthChecker) Check(ctx context.Context) HealthReport {
+	report := HealthReport{Status: StatusOK, Services: make(map[string]BackendHealth, len(h.Backends))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, b := range h.Backends {
+		wg.Add(1)
+		go func(b Backend) {
+			defer wg.Done()
+			health := h.check(ctx, b)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Services[b.Name] = health
+			if health.Status != StatusUp {
+				report.Status = StatusDegraded
+			}
+		}(b)
+	}
+	wg.Wait()
+	return report
+}
+
+func (h *HealthChecker) check(ctx context.Context, b Backend) BackendHealth {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.URL.JoinPath("/readyz").String(), nil)
+	if err != nil {
+		return BackendHealth{Status: StatusDown, Error: err.Error()}
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return BackendHealth{Status: StatusDown, Error: err.Error()}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return BackendHealth{Status: StatusDown, Error: fmt.Sprintf("health check returned %d", resp.StatusCode)}
+	}
+	return BackendHealth{Status: StatusUp}
+}
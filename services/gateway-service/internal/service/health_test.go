@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func backend(t *testing.T, name string, status int) Backend {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/readyz", r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return Backend{Name: name, URL: u}
+}
+
+func TestHealthChecker_AllUp(t *testing.T) {
+	// Arrange
+	checker := &HealthChecker{Backends: []Backend{
+		backend(t, Create, http.StatusOK),
+		backend(t, Read, http.StatusOK),
+	}}
+
+	// Act
+	report := checker.Check(context.Background())
+
+	// Assert - Regla de negocio: El gateway está sano cuando todos los servicios lo están
+	assert.True(t, report.Healthy())
+	assert.Equal(t, StatusUp, report.Services[Create].Status)
+	assert.Equal(t, StatusUp, report.Services[Read].Status)
+}
+
+func TestHealthChecker_OneDown(t *testing.T) {
+	// Arrange
+	unreachable, _ := url.Parse("http://127.0.0.1:1")
+	checker := &HealthChecker{Backends: []Backend{
+		backend(t, Create, http.StatusOK),
+		backend(t, Read, http.StatusInternalServerError),
+		{Name: Delete, URL: unreachable},
+	}}
+
+	// Act
+	report := checker.Check(context.Background())
+
+	// Assert - Regla de negocio: Un servicio caído degrada el estado del gateway
+	assert.False(t, report.Healthy())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, StatusUp, report.Services[Create].Status)
+	assert.Equal(t, StatusDown, report.Services[Read].Status)
+	assert.Contains(t, report.Services[Read].Error, "500")
+	assert.Equal(t, StatusDown, report.Services[Delete].Status)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Names of the backends the gateway routes to.
+const (
+	Create = "create"
+	Read   = "read"
+	Update = "update"
+	Delete = "delete"
+)
+
+var (
+	ErrRouteNotFound    = errors.New("route not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+)
+
+// Route returns the backend that serves method on path. When the path is
+// known but the method is not, it returns ErrMethodNotAllowed together
+// with the methods the path accepts.
+func Route(method, path string) (string, []string, error) {
+	switch {
+	case path == "/products":
+		return byMethod(method, map[string]string{
+			http.MethodGet:  Read,
+			http.MethodPost: Create,
+		})
+	case path == "/products:bulk":
+		return byMethod(method, map[string]string{
+			http.MethodPost:   Create,
+			http.MethodPatch:  Update,
+			http.MethodDelete: Delete,
+		})
+	case path == "/products/search":
+		return byMethod(method, map[string]string{
+			http.MethodGet: Read,
+		})
+	case path == "/admin/api-keys":
+		return byMethod(method, map[string]string{
+			http.MethodGet:  Create,
+			http.MethodPost: Create,
+		})
+	case strings.HasPrefix(path, "/admin/api-keys/"):
+		id := path[len("/admin/api-keys/"):]
+		if id == "" || strings.Contains(id, "/") {
+			return "", nil, ErrRouteNotFound
+		}
+		return byMethod(method, map[string]string{
+			http.MethodDelete: Create,
+		})
+	case strings.HasPrefix(path, "/products/"):
+		id, sub, nested := strings.Cut(path[len("/products/"):], "/")
+		if id == "" {
+			return "", nil, ErrRouteNotFound
+		}
+		if nested {
+			return subresource(method, sub)
+		}
+		if strings.HasSuffix(id, ":restore") {
+			return byMethod(method, map[string]string{
+				http.MethodPost: Delete,
+			})
+		}
+		if strings.HasSuffix(id, ":rollback") {
+			return byMethod(method, map[string]string{
+				http.MethodPost: Update,
+			})
+		}
+		return byMethod(method, map[string]string{
+			http.MethodGet:    Read,
+			http.MethodPut:    Update,
+			http.MethodPatch:  Update,
+			http.MethodDelete: Delete,
+		})
+	}
+	return "", nil, ErrRouteNotFound
+}
+
+// subresource routes the path below /products/{id}/.
+func subresource(method, sub string) (string, []string, error) {
+	name, version, one := strings.Cut(sub, "/")
+	switch {
+	case sub == "history":
+		return byMethod(method, map[string]string{
+			http.MethodGet: Read,
+		})
+	case name == "revisions" && (!one || (version != "" && !strings.Contains(version, "/"))):
+		return byMethod(method, map[string]string{
+			http.MethodGet: Update,
+		})
+	}
+	return "", nil, ErrRouteNotFound
+}
+
+func byMethod(method string, routes map[string]string) (string, []string, error) {
+	if backend, ok := routes[method]; ok {
+		return backend, nil, nil
+	}
+	var allowed []string
+	for _, m := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		if _, ok := routes[m]; ok {
+			allowed = append(allowed, m)
+		}
+	}
+	return "", allowed, ErrMethodNotAllowed
+}
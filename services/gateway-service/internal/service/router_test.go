@@ -0,0 +1,61 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute_ProductRoutes(t *testing.T) {
+	id := "/products/507f1f77bcf86cd799439011"
+	cases := []struct {
+		method, path, backend string
+	}{
+		{http.MethodGet, "/products", Read},
+		{http.MethodPost, "/products", Create},
+		{http.MethodGet, "/products/search", Read},
+		{http.MethodGet, id, Read},
+		{http.MethodPut, id, Update},
+		{http.MethodPatch, id, Update},
+		{http.MethodDelete, id, Delete},
+		{http.MethodPost, id + ":restore", Delete},
+		{http.MethodGet, id + "/history", Read},
+		{http.MethodGet, id + "/revisions", Update},
+		{http.MethodGet, id + "/revisions/3", Update},
+		{http.MethodPost, id + ":rollback", Update},
+		{http.MethodPost, "/products:bulk", Create},
+		{http.MethodPatch, "/products:bulk", Update},
+		{http.MethodDelete, "/products:bulk", Delete},
+		{http.MethodGet, "/admin/api-keys", Create},
+		{http.MethodPost, "/admin/api-keys", Create},
+		{http.MethodDelete, "/admin/api-keys/507f1f77bcf86cd799439011", Create},
+	}
+	for _, c := range cases {
+		// Act
+		backend, _, err := Route(c.method, c.path)
+
+		// Assert - Regla de negocio: Cada operación se envía al servicio que la implementa
+		assert.NoError(t, err, "%s %s", c.method, c.path)
+		assert.Equal(t, c.backend, backend, "%s %s", c.method, c.path)
+	}
+}
+
+func TestRoute_MethodNotAllowed(t *testing.T) {
+	// Act
+	_, allowed, err := Route(http.MethodDelete, "/products")
+
+	// Assert - Regla de negocio: Un método no soportado informa los métodos válidos
+	assert.ErrorIs(t, err, ErrMethodNotAllowed)
+	assert.Equal(t, []string{http.MethodGet, http.MethodPost}, allowed)
+}
+
+func TestRoute_UnknownPath(t *testing.T) {
+	for _, path := range []string{"/", "/orders", "/products/", "/products/a/b", "/products/a/history/1", "/products/a/revisions/", "/products/a/revisions/1/x"} {
+		// Act
+		_, _, err := Route(http.MethodGet, path)
+
+		// Assert - Regla de negocio: Rutas fuera de /products no se reenvían
+		assert.ErrorIs(t, err, ErrRouteNotFound, path)
+	}
+}
@@ -1,11 +1,29 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/apikey"
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/database"
+	"github.com/blandoncj/go-products-api/pkg/health"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/ratelimit"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
 	"github.com/blandoncj/go-products-api/services/read-service/internal/controller"
+	"github.com/blandoncj/go-products-api/services/read-service/internal/repository"
+	"github.com/blandoncj/go-products-api/services/read-service/internal/service"
 )
 
 func main() {
@@ -13,10 +31,113 @@ func main() {
 	if port == "" {
 		port = "8082"
 	}
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := health.Probe(context.Background(), "http://127.0.0.1:"+port+"/readyz"); err != nil {
+			log.Fatalf("read service: %v", err)
+		}
+		return
+	}
+
+	logger := logging.New("read-service")
+	slog.SetDefault(logger)
+
+	cfg, err := server.LoadConfig(":" + port)
+	if err != nil {
+		log.Fatalf("read service: %v", err)
+	}
 
-	handler := controller.NewHandler()
-	log.Printf("Read service listening on :%s", port)
-	if err := http.ListenAndServe(":"+port, handler); err != nil {
+	closeTracing, err := tracing.Setup(context.Background(), "read-service")
+	if err != nil {
+		log.Fatalf("read service: %v", err)
+	}
+	verifier, err := auth.FromEnv()
+	if err != nil {
+		log.Fatalf("read service: %v", err)
+	}
+	if verifier == nil {
+		log.Printf("Read service running without authentication")
+	}
+	limiter, err := ratelimit.FromEnv()
+	if err != nil {
+		log.Fatalf("read service: %v", err)
+	}
+	m := metrics.New("read-service")
+	repo, stores, checks, closeRepo, err := newRepository(m)
+	if err != nil {
+		log.Fatalf("read service: %v", err)
+	}
+	keyManager := apikey.NewManager(stores.keys)
+	if verifier != nil {
+		verifier.APIKeys = keyManager
+	}
+	repo = repository.NewInstrumentedRepository(repo, m)
+	svc, err := newService(repo, stores.audit)
+	if err != nil {
+		log.Fatalf("read service: %v", err)
+	}
+	handler := http.NewServeMux()
+	health.NewChecker("read-service", checks...).Register(handler)
+	handler.Handle("/metrics", m.Handler())
+	products := controller.NewHandler(svc)
+	// the history names who changed what, so only admins may read it
+	handler.Handle("GET /products/{id}/history", auth.RequireRole(verifier, auth.RoleAdmin)(limiter.Middleware(products)))
+	handler.Handle("/", auth.Require(verifier, auth.OpRead)(limiter.Middleware(products)))
+	log.Printf("Read service listening on %s", cfg.Addr)
+	root := tracing.Middleware(logging.Middleware(logger)(m.Middleware(handler)))
+	if err := server.Run(context.Background(), cfg, root, closeRepo, closeTracing); err != nil {
 		log.Fatalf("read service failed: %v", err)
 	}
+	log.Printf("Read service stopped")
+}
+
+func newService(repo repository.ProductRepositoryInterface, history audit.Store) (*service.ProductService, error) {
+	svc := service.NewProductService(repo)
+	svc.Audit = history
+	if v := os.Getenv("SEARCH_MIN_SCORE"); v != "" {
+		minScore, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SEARCH_MIN_SCORE: %q", v)
+		}
+		svc.SearchMinScore = minScore
+	}
+	return svc, nil
+}
+
+func newRepository(m *metrics.Metrics) (repository.ProductRepositoryInterface, storeSet, []health.Check, server.Closer, error) {
+	memory, err := memstore.Enabled()
+	if err != nil {
+		return nil, storeSet{}, nil, nil, err
+	}
+	if memory {
+		store, err := memstore.FromEnv()
+		if err != nil {
+			return nil, storeSet{}, nil, nil, err
+		}
+		log.Printf("Read service using in-memory storage")
+		return repository.NewMemoryRepository(store), storeSet{keys: apikey.NewSharedMemoryStore(store.File()), audit: audit.NewSharedMemoryStore(store.File())}, nil, server.NopCloser, nil
+	}
+
+	db, err := database.Open(context.Background(), m.MongoOption(), tracing.MongoOption())
+	if err != nil {
+		return nil, storeSet{}, nil, nil, err
+	}
+	repo := repository.NewProductRepository(db)
+	ctxIdx, cancelIdx := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelIdx()
+	if err := repo.EnsureIndexes(ctxIdx); err != nil {
+		_ = db.Client().Disconnect(context.Background())
+		return nil, storeSet{}, nil, nil, fmt.Errorf("create indexes: %w", err)
+	}
+	history := audit.NewMongoStore(db)
+	if err := history.EnsureIndexes(ctxIdx); err != nil {
+		_ = db.Client().Disconnect(context.Background())
+		return nil, storeSet{}, nil, nil, fmt.Errorf("create indexes: %w", err)
+	}
+	return repo, storeSet{keys: apikey.NewMongoStore(db), audit: history}, []health.Check{database.HealthCheck(db)}, db.Client().Disconnect, nil
+}
+
+// storeSet holds the stores the service keeps next to its products.
+type storeSet struct {
+	keys  apikey.Store
+	audit audit.Store
 }
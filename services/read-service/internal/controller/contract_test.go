@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// contractDir holds the documents shared with create-service's contract
+// test.
+const contractDir = "../../../../pkg/model/testdata/"
+
+func TestContract_ReadServesDocumentsWrittenByCreate(t *testing.T) {
+	// Arrange
+	goldenDoc, err := os.ReadFile(contractDir + "product_document.json")
+	require.NoError(t, err)
+	goldenBody, err := os.ReadFile(contractDir + "product_response.json")
+	require.NoError(t, err)
+	var raw bson.Raw
+	require.NoError(t, bson.UnmarshalExtJSON(goldenDoc, true, &raw))
+	var product model.Product
+	require.NoError(t, bson.Unmarshal(raw, &product), "Un documento de create-service debe decodificarse")
+	repo := newFakeRepository(product)
+
+	// Act
+	rec := serve(repo, http.MethodGet, "/products/"+product.ID.Hex(), nil)
+
+	// Assert - Regla de negocio: read-service devuelve el producto con el mismo formato que create-service
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, string(goldenBody), rec.Body.String())
+}
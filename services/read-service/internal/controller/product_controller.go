@@ -1,58 +1,20 @@
 package controller
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"time"
+	"net/url"
+	"strconv"
+	"strings"
 
-	"github.com/blandoncj/go-products-api/services/read-service/internal/repository"
+	"github.com/blandoncj/go-products-api/pkg/model"
 	"github.com/blandoncj/go-products-api/services/read-service/internal/service"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func NewHandler() http.Handler {
-	// variables de entorno esperadas
-	user := os.Getenv("MONGO_ROOT_USERNAME")
-	pass := os.Getenv("MONGO_ROOT_PASSWORD")
-	host := os.Getenv("MONGO_HOST")
-	port := os.Getenv("MONGO_PORT")
-	if port == "" {
-		port = "27017"
-	}
-	dbname := os.Getenv("MONGO_DB")
-	if dbname == "" {
-		dbname = "parcialdb"
-	}
-
-	if user == "" || pass == "" || host == "" {
-		panic("missing MongoDB environment variables for read service")
-	}
-
-	mongoURI := fmt.Sprintf("mongodb://%s:%s@%s:%s/?authSource=admin", user, pass, host, port)
-
-	clientOpts := options.Client().ApplyURI(mongoURI)
-	// conectar con timeout
-	ctxConn, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	client, err := mongo.Connect(ctxConn, clientOpts)
-	if err != nil {
-		panic(fmt.Sprintf("mongo connect error: %v", err))
-	}
-	// ping
-	ctxPing, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelPing()
-	if err := client.Ping(ctxPing, nil); err != nil {
-		panic(fmt.Sprintf("mongo ping error: %v", err))
-	}
-
-	db := client.Database(dbname)
-	repo := repository.NewProductRepository(db)
-	svc := service.NewProductService(repo)
-
+func NewHandler(svc *service.ProductService) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -65,14 +27,196 @@ func NewHandler() http.Handler {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		products, err := svc.GetAll(r.Context())
+		params, err := parseListParams(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		page, err := svc.List(r.Context(), params)
+		if errors.Is(err, service.ErrInvalidQuery) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "error reading products: "+err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(page)
+	})
+
+	mux.HandleFunc("/products/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		q := r.URL.Query()
+		params := service.SearchParams{Query: q.Get("q")}
+		var err error
+		if params.Limit, err = intParam(q, "limit"); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if params.MinScore, err = floatParam(q, "min_score"); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if params.IncludeDeleted, err = boolParam(q, "include_deleted"); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		results, err := svc.Search(r.Context(), params)
+		if errors.Is(err, service.ErrInvalidQuery) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "error searching products: "+err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(results)
+	})
+
+	mux.HandleFunc("/products/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		idHex, sub, _ := strings.Cut(r.URL.Path[len("/products/"):], "/")
+		objID, err := primitive.ObjectIDFromHex(idHex)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid id format")
+			return
+		}
+		switch sub {
+		case "":
+		case "history":
+			history(w, r, svc, objID)
+			return
+		default:
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		includeDeleted, err := boolParam(r.URL.Query(), "include_deleted")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		product, err := svc.GetByID(r.Context(), objID, includeDeleted)
+		if errors.Is(err, service.ErrProductNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
 		if err != nil {
-			http.Error(w, "error reading products: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "error reading product: "+err.Error())
+			return
+		}
+		etag := model.ETag(product.Version)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(products)
+		_ = json.NewEncoder(w).Encode(product)
 	})
 
 	return mux
 }
+
+func history(w http.ResponseWriter, r *http.Request, svc *service.ProductService, id primitive.ObjectID) {
+	q := r.URL.Query()
+	pageSize, err := intParam(q, "page_size")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	page, err := svc.History(r.Context(), id, pageSize, q.Get("page_token"))
+	if errors.Is(err, service.ErrInvalidQuery) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "error reading history: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+func parseListParams(q url.Values) (service.ListParams, error) {
+	params := service.ListParams{
+		PageToken:  q.Get("page_token"),
+		Sort:       q.Get("sort"),
+		Order:      q.Get("order"),
+		NamePrefix: q.Get("name_prefix"),
+	}
+
+	var err error
+	if params.PageSize, err = intParam(q, "page_size"); err != nil {
+		return params, err
+	}
+	if params.Page, err = intParam(q, "page"); err != nil {
+		return params, err
+	}
+	if v := q.Get("min_stock"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return params, fmt.Errorf("invalid min_stock: %q", v)
+		}
+		params.MinStock = &n
+	}
+	if params.MinPrice, err = floatParam(q, "min_price"); err != nil {
+		return params, err
+	}
+	if params.MaxPrice, err = floatParam(q, "max_price"); err != nil {
+		return params, err
+	}
+	if params.IncludeDeleted, err = boolParam(q, "include_deleted"); err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+func intParam(q url.Values, key string) (int, error) {
+	v := q.Get(key)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q", key, v)
+	}
+	return n, nil
+}
+
+func floatParam(q url.Values, key string) (*float64, error) {
+	v := q.Get(key)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %q", key, v)
+	}
+	return &f, nil
+}
+
+func boolParam(q url.Values, key string) (bool, error) {
+	v := q.Get(key)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %q", key, v)
+	}
+	return b, nil
+}
@@ -0,0 +1,379 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/services/read-service/internal/repository"
+	"github.com/blandoncj/go-products-api/services/read-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeRepository keeps products in a map. It honours the filters used by
+// the handlers but only sorts by _id. Setting err makes every call fail.
+type fakeRepository struct {
+	mu       sync.Mutex
+	products map[primitive.ObjectID]model.Product
+	err      error
+}
+
+func newFakeRepository(products ...model.Product) *fakeRepository {
+	f := &fakeRepository{products: map[primitive.ObjectID]model.Product{}}
+	for _, p := range products {
+		f.products[p.ID] = p
+	}
+	return f
+}
+
+func (f *fakeRepository) FindAll(ctx context.Context) ([]model.Product, error) {
+	return f.FindPage(ctx, repository.ListOptions{})
+}
+
+func (f *fakeRepository) FindByID(ctx context.Context, id any, includeDeleted bool) (*model.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	p, ok := f.products[id.(primitive.ObjectID)]
+	if !ok || (p.DeletedAt != nil && !includeDeleted) {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &p, nil
+}
+
+func (f *fakeRepository) FindPage(ctx context.Context, opts repository.ListOptions) ([]model.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	items := f.matching(opts.Filter)
+	if opts.After != nil {
+		after := opts.After.ID.(primitive.ObjectID)
+		n := sort.Search(len(items), func(i int) bool {
+			return items[i].ID.Hex() > after.Hex()
+		})
+		items = items[n:]
+	}
+	items = items[min(int(opts.Skip), len(items)):]
+	if opts.Limit > 0 {
+		items = items[:min(int(opts.Limit), len(items))]
+	}
+	return items, nil
+}
+
+func (f *fakeRepository) Count(ctx context.Context, filter repository.ProductFilter) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return 0, f.err
+	}
+	return int64(len(f.matching(filter))), nil
+}
+
+func (f *fakeRepository) Search(ctx context.Context, query string, minScore float64, limit int64, includeDeleted bool) ([]repository.SearchHit, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	hits := []repository.SearchHit{}
+	for _, p := range f.matching(repository.ProductFilter{IncludeDeleted: includeDeleted}) {
+		if strings.Contains(strings.ToLower(p.Name), strings.ToLower(query)) && int64(len(hits)) < limit {
+			hits = append(hits, repository.SearchHit{Product: p, Score: 1})
+		}
+	}
+	return hits, nil
+}
+
+func (f *fakeRepository) matching(filter repository.ProductFilter) []model.Product {
+	items := []model.Product{}
+	for _, p := range f.products {
+		switch {
+		case p.DeletedAt != nil && !filter.IncludeDeleted:
+		case filter.MinPrice != nil && p.Price < *filter.MinPrice:
+		case filter.MaxPrice != nil && p.Price > *filter.MaxPrice:
+		case filter.MinStock != nil && p.Stock < *filter.MinStock:
+		case !strings.HasPrefix(p.Name, filter.NamePrefix):
+		default:
+			items = append(items, p)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID.Hex() < items[j].ID.Hex()
+	})
+	return items
+}
+
+func serve(repo *fakeRepository, method, target string, header http.Header) *httptest.ResponseRecorder {
+	handler := NewHandler(service.NewProductService(repo))
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func catalog() (*fakeRepository, []model.Product) {
+	deletedAt := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	products := []model.Product{
+		{ID: primitive.NewObjectID(), Name: "Keyboard", Price: 75, Stock: 30, Version: 1},
+		{ID: primitive.NewObjectID(), Name: "Laptop", Price: 1500, Stock: 10, Version: 3},
+		{ID: primitive.NewObjectID(), Name: "Mouse", Price: 25, Stock: 0, Version: 1},
+		{ID: primitive.NewObjectID(), Name: "Monitor", Price: 300, Stock: 5, Version: 2, DeletedAt: &deletedAt},
+	}
+	return newFakeRepository(products...), products
+}
+
+func TestHandler_Health(t *testing.T) {
+	rec := serve(newFakeRepository(), http.MethodGet, "/health", nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandler_List_Pagination(t *testing.T) {
+	// Arrange
+	repo, _ := catalog()
+
+	// Act
+	first := serve(repo, http.MethodGet, "/products?page_size=2", nil)
+	var page service.ProductPage
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &page))
+	second := serve(repo, http.MethodGet, "/products?page_size=2&page_token="+page.NextPageToken, nil)
+	var next service.ProductPage
+	require.NoError(t, json.Unmarshal(second.Body.Bytes(), &next))
+
+	// Assert - Regla de negocio: El token de página continúa donde terminó la anterior
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, int64(3), page.TotalCount, "Los productos eliminados no se listan")
+	assert.Len(t, page.Items, 2)
+	require.NotEmpty(t, page.NextPageToken)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Len(t, next.Items, 1)
+	assert.Empty(t, next.NextPageToken)
+}
+
+func TestHandler_List_Filters(t *testing.T) {
+	// Arrange
+	repo, _ := catalog()
+
+	// Act
+	rec := serve(repo, http.MethodGet, "/products?min_price=50&min_stock=1&include_deleted=true", nil)
+
+	// Assert - Regla de negocio: Los filtros se combinan y los eliminados se incluyen a pedido
+	require.Equal(t, http.StatusOK, rec.Code)
+	var page service.ProductPage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	var names []string
+	for _, p := range page.Items {
+		names = append(names, p.Name)
+	}
+	assert.ElementsMatch(t, []string{"Keyboard", "Laptop", "Monitor"}, names)
+}
+
+func TestHandler_List_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		target string
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodPost, "/products", nil, http.StatusMethodNotAllowed},
+		{"page_size invalido", http.MethodGet, "/products?page_size=abc", nil, http.StatusBadRequest},
+		{"page_size fuera de rango", http.MethodGet, "/products?page_size=1000", nil, http.StatusBadRequest},
+		{"min_stock invalido", http.MethodGet, "/products?min_stock=x", nil, http.StatusBadRequest},
+		{"min_price invalido", http.MethodGet, "/products?min_price=x", nil, http.StatusBadRequest},
+		{"max_price invalido", http.MethodGet, "/products?max_price=x", nil, http.StatusBadRequest},
+		{"include_deleted invalido", http.MethodGet, "/products?include_deleted=x", nil, http.StatusBadRequest},
+		{"orden invalido", http.MethodGet, "/products?sort=color", nil, http.StatusBadRequest},
+		{"token invalido", http.MethodGet, "/products?page_token=not-a-token!", nil, http.StatusBadRequest},
+		{"error de base de datos", http.MethodGet, "/products", errors.New("timeout de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo, _ := catalog()
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, c.target, nil)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+			if c.status != http.StatusMethodNotAllowed {
+				assert.Contains(t, rec.Body.String(), `"error"`)
+			}
+		})
+	}
+}
+
+func TestHandler_Search(t *testing.T) {
+	// Arrange
+	repo, _ := catalog()
+
+	// Act
+	rec := serve(repo, http.MethodGet, "/products/search?q=laptop", nil)
+
+	// Assert - Regla de negocio: La búsqueda resalta los términos encontrados
+	require.Equal(t, http.StatusOK, rec.Code)
+	var results []service.SearchResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{"<em>Laptop</em>"}, results[0].Highlights["name"])
+}
+
+func TestHandler_Search_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		target string
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodPost, "/products/search?q=laptop", nil, http.StatusMethodNotAllowed},
+		{"sin consulta", http.MethodGet, "/products/search", nil, http.StatusBadRequest},
+		{"limit invalido", http.MethodGet, "/products/search?q=laptop&limit=x", nil, http.StatusBadRequest},
+		{"min_score invalido", http.MethodGet, "/products/search?q=laptop&min_score=x", nil, http.StatusBadRequest},
+		{"include_deleted invalido", http.MethodGet, "/products/search?q=laptop&include_deleted=x", nil, http.StatusBadRequest},
+		{"error de base de datos", http.MethodGet, "/products/search?q=laptop", errors.New("timeout de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo, _ := catalog()
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, c.target, nil)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+		})
+	}
+}
+
+func TestHandler_GetByID(t *testing.T) {
+	// Arrange
+	repo, products := catalog()
+	laptop := products[1]
+
+	// Act
+	rec := serve(repo, http.MethodGet, "/products/"+laptop.ID.Hex(), nil)
+
+	// Assert - Regla de negocio: El producto se devuelve con su versión como ETag
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+	assert.Contains(t, rec.Body.String(), `"name":"Laptop"`)
+}
+
+func TestHandler_GetByID_NotModified(t *testing.T) {
+	// Arrange
+	repo, products := catalog()
+	laptop := products[1]
+
+	// Act
+	rec := serve(repo, http.MethodGet, "/products/"+laptop.ID.Hex(), http.Header{"If-None-Match": {`"3"`}})
+
+	// Assert - Regla de negocio: Si el cliente tiene la versión vigente no se reenvía el cuerpo
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestHandler_GetByID_Deleted(t *testing.T) {
+	// Arrange
+	repo, products := catalog()
+	target := "/products/" + products[3].ID.Hex()
+
+	// Act
+	hidden := serve(repo, http.MethodGet, target, nil)
+	shown := serve(repo, http.MethodGet, target+"?include_deleted=true", nil)
+
+	// Assert - Regla de negocio: Un producto eliminado solo se muestra si se pide explícitamente
+	assert.Equal(t, http.StatusNotFound, hidden.Code)
+	assert.Equal(t, http.StatusOK, shown.Code)
+	assert.Contains(t, shown.Body.String(), `"deleted_at"`)
+}
+
+func TestHandler_GetByID_Errors(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		target string
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodDelete, "/products/" + primitive.NewObjectID().Hex(), nil, http.StatusMethodNotAllowed},
+		{"id invalido", http.MethodGet, "/products/abc", nil, http.StatusBadRequest},
+		{"include_deleted invalido", http.MethodGet, "/products/" + primitive.NewObjectID().Hex() + "?include_deleted=x", nil, http.StatusBadRequest},
+		{"no encontrado", http.MethodGet, "/products/" + primitive.NewObjectID().Hex(), nil, http.StatusNotFound},
+		{"error de base de datos", http.MethodGet, "/products/" + primitive.NewObjectID().Hex(), errors.New("timeout de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo, _ := catalog()
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, c.target, nil)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+		})
+	}
+}
+
+func TestHandler_History(t *testing.T) {
+	// Arrange
+	repo, products := catalog()
+	laptop := products[1]
+	store := audit.NewMemoryStore()
+	for _, action := range []string{audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete} {
+		require.NoError(t, store.Insert(context.Background(), audit.Entry{ID: primitive.NewObjectID(), ProductID: laptop.ID, Action: action}))
+	}
+	svc := service.NewProductService(repo)
+	svc.Audit = store
+	handler := NewHandler(svc)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+	target := "/products/" + laptop.ID.Hex() + "/history"
+
+	// Act
+	first := get(target + "?page_size=2")
+	var page service.HistoryPage
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &page))
+	second := get(target + "?page_size=2&page_token=" + page.NextPageToken)
+	badToken := get(target + "?page_token=x")
+	badID := get("/products/abc/history")
+
+	// Assert - Regla de negocio: El historial se pagina del cambio más reciente al más antiguo
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, audit.ActionDelete, page.Items[0].Action)
+	assert.Equal(t, audit.ActionUpdate, page.Items[1].Action)
+	assert.NotEmpty(t, page.NextPageToken)
+	assert.Contains(t, second.Body.String(), `"action":"create"`)
+	assert.NotContains(t, second.Body.String(), "next_page_token")
+	assert.Equal(t, http.StatusBadRequest, badToken.Code)
+	assert.Equal(t, http.StatusBadRequest, badID.Code)
+}
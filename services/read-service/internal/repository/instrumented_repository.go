@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/model"
+)
+
+// InstrumentedRepository records the duration and errors of every call to
+// the repository it wraps.
+type InstrumentedRepository struct {
+	next    ProductRepositoryInterface
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedRepository(next ProductRepositoryInterface, m *metrics.Metrics) *InstrumentedRepository {
+	return &InstrumentedRepository{next: next, metrics: m}
+}
+
+func (r *InstrumentedRepository) FindAll(ctx context.Context) (products []model.Product, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "FindAll", time.Now(), &err)
+	return r.next.FindAll(ctx)
+}
+
+func (r *InstrumentedRepository) FindByID(ctx context.Context, id any, includeDeleted bool) (product *model.Product, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "FindByID", time.Now(), &err)
+	return r.next.FindByID(ctx, id, includeDeleted)
+}
+
+func (r *InstrumentedRepository) FindPage(ctx context.Context, opts ListOptions) (products []model.Product, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "FindPage", time.Now(), &err)
+	return r.next.FindPage(ctx, opts)
+}
+
+func (r *InstrumentedRepository) Count(ctx context.Context, filter ProductFilter) (n int64, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "Count", time.Now(), &err)
+	return r.next.Count(ctx, filter)
+}
+
+func (r *InstrumentedRepository) Search(ctx context.Context, query string, minScore float64, limit int64, includeDeleted bool) (hits []SearchHit, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "Search", time.Now(), &err)
+	return r.next.Search(ctx, query, minScore, limit, includeDeleted)
+}
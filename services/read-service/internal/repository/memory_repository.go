@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRepository serves products from a memstore.Store. Search scores
+// follow the text index weights: 10 per name match, 1 per description
+// match.
+type MemoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) *MemoryRepository {
+	return &MemoryRepository{store: store}
+}
+
+func (r *MemoryRepository) FindAll(ctx context.Context) ([]model.Product, error) {
+	return r.matching(ProductFilter{}), nil
+}
+
+func (r *MemoryRepository) FindByID(ctx context.Context, id any, includeDeleted bool) (*model.Product, error) {
+	oid, ok := id.(primitive.ObjectID)
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	p, ok := r.store.Get(oid)
+	if !ok || (p.DeletedAt != nil && !includeDeleted) {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &p, nil
+}
+
+func (r *MemoryRepository) FindPage(ctx context.Context, opts ListOptions) ([]model.Product, error) {
+	items := r.matching(opts.Filter)
+	less := func(a, b model.Product) bool {
+		if opts.SortField != "_id" {
+			if c := compare(sortValue(a, opts.SortField), sortValue(b, opts.SortField)); c != 0 {
+				return c < 0
+			}
+		}
+		return a.ID.Hex() < b.ID.Hex()
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if opts.SortDesc {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+
+	if opts.After != nil {
+		afterID, _ := opts.After.ID.(primitive.ObjectID)
+		past := func(p model.Product) bool {
+			c := 0
+			if opts.SortField != "_id" {
+				c = compare(sortValue(p, opts.SortField), opts.After.Value)
+			}
+			if c == 0 {
+				c = strings.Compare(p.ID.Hex(), afterID.Hex())
+			}
+			if opts.SortDesc {
+				return c < 0
+			}
+			return c > 0
+		}
+		kept := items[:0]
+		for _, p := range items {
+			if past(p) {
+				kept = append(kept, p)
+			}
+		}
+		items = kept
+	}
+
+	items = items[min(int(opts.Skip), len(items)):]
+	if opts.Limit > 0 {
+		items = items[:min(int(opts.Limit), len(items))]
+	}
+	return items, nil
+}
+
+func (r *MemoryRepository) Count(ctx context.Context, filter ProductFilter) (int64, error) {
+	return int64(len(r.matching(filter))), nil
+}
+
+func (r *MemoryRepository) Search(ctx context.Context, query string, minScore float64, limit int64, includeDeleted bool) ([]SearchHit, error) {
+	terms := SearchTerms(query)
+	hits := []SearchHit{}
+	for _, p := range r.store.All() {
+		if p.DeletedAt != nil && !includeDeleted {
+			continue
+		}
+		score := 10*matches(p.Name, terms) + matches(p.Description, terms)
+		if score == 0 || score < minScore {
+			continue
+		}
+		hits = append(hits, SearchHit{Product: p, Score: score})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if int64(len(hits)) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// matching returns the products accepted by filter, ordered by _id.
+func (r *MemoryRepository) matching(filter ProductFilter) []model.Product {
+	items := []model.Product{}
+	for _, p := range r.store.All() {
+		switch {
+		case p.DeletedAt != nil && !filter.IncludeDeleted:
+		case filter.MinPrice != nil && p.Price < *filter.MinPrice:
+		case filter.MaxPrice != nil && p.Price > *filter.MaxPrice:
+		case filter.MinStock != nil && p.Stock < *filter.MinStock:
+		case !strings.HasPrefix(p.Name, filter.NamePrefix):
+		default:
+			items = append(items, p)
+		}
+	}
+	return items
+}
+
+func sortValue(p model.Product, field string) any {
+	switch field {
+	case "name":
+		return p.Name
+	case "price":
+		return p.Price
+	case "stock":
+		return p.Stock
+	}
+	return nil
+}
+
+// compare orders two sort values. Numbers may arrive as int or, from a
+// decoded page token, as float64.
+func compare(a, b any) int {
+	if sa, ok := a.(string); ok {
+		sb, _ := b.(string)
+		return strings.Compare(sa, sb)
+	}
+	fa, fb := number(a), number(b)
+	switch {
+	case fa < fb:
+		return -1
+	case fa > fb:
+		return 1
+	}
+	return 0
+}
+
+func number(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// SearchTerms splits a $text query into lowercase words, leaving out
+// negated terms since they never appear in a matching document.
+func SearchTerms(query string) []string {
+	var terms []string
+	for _, field := range strings.Fields(strings.ToLower(query)) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		terms = append(terms, strings.FieldsFunc(field, isNotWordRune)...)
+	}
+	return terms
+}
+
+// matches counts the words of text that equal one of the search terms.
+func matches(text string, terms []string) float64 {
+	var n float64
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isNotWordRune) {
+		for _, term := range terms {
+			if word == term {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func memoryCatalog(t *testing.T) (*MemoryRepository, []model.Product) {
+	t.Helper()
+	deletedAt := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	products := []model.Product{
+		{ID: primitive.NewObjectID(), Name: "Keyboard", Description: "Mechanical keyboard", Price: 75, Stock: 30},
+		{ID: primitive.NewObjectID(), Name: "Laptop", Description: "Gaming laptop with keyboard", Price: 1500, Stock: 10},
+		{ID: primitive.NewObjectID(), Name: "Mouse", Price: 75, Stock: 0},
+		{ID: primitive.NewObjectID(), Name: "Monitor", Price: 300, Stock: 5, DeletedAt: &deletedAt},
+	}
+	store := memstore.New()
+	for _, p := range products {
+		require.NoError(t, store.Insert(p))
+	}
+	return NewMemoryRepository(store), products
+}
+
+func names(products []model.Product) []string {
+	out := []string{}
+	for _, p := range products {
+		out = append(out, p.Name)
+	}
+	return out
+}
+
+func TestMemoryRepository_FindByID_HidesDeleted(t *testing.T) {
+	// Arrange
+	repo, products := memoryCatalog(t)
+	ctx := context.Background()
+
+	// Act
+	_, hiddenErr := repo.FindByID(ctx, products[3].ID, false)
+	deleted, err := repo.FindByID(ctx, products[3].ID, true)
+
+	// Assert - Regla de negocio: Los eliminados solo se ven al pedirlos explícitamente
+	assert.ErrorIs(t, hiddenErr, mongo.ErrNoDocuments)
+	require.NoError(t, err)
+	assert.Equal(t, "Monitor", deleted.Name)
+}
+
+func TestMemoryRepository_FindPage_SortAndCursor(t *testing.T) {
+	// Arrange
+	repo, _ := memoryCatalog(t)
+	ctx := context.Background()
+	opts := ListOptions{SortField: "price", SortDesc: true, Limit: 2}
+
+	// Act
+	first, err := repo.FindPage(ctx, opts)
+	require.NoError(t, err)
+	last := first[len(first)-1]
+	opts.After = &Cursor{Value: last.Price, ID: last.ID}
+	second, err := repo.FindPage(ctx, opts)
+	require.NoError(t, err)
+
+	// Assert - Regla de negocio: El cursor continúa justo después del último elemento
+	assert.Equal(t, "Laptop", first[0].Name)
+	assert.Len(t, append(first, second...), 3, "No se repiten ni se pierden productos entre páginas")
+	assert.ElementsMatch(t, []string{"Laptop", "Keyboard", "Mouse"}, names(append(first, second...)))
+}
+
+func TestMemoryRepository_Count_Filter(t *testing.T) {
+	// Arrange
+	repo, _ := memoryCatalog(t)
+	minStock := 1
+
+	// Act
+	n, err := repo.Count(context.Background(), ProductFilter{MinStock: &minStock})
+
+	// Assert - Regla de negocio: Los filtros excluyen también a los eliminados
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestMemoryRepository_Search_WeightsName(t *testing.T) {
+	// Arrange
+	repo, _ := memoryCatalog(t)
+
+	// Act
+	hits, err := repo.Search(context.Background(), "keyboard", 0, 10, false)
+
+	// Assert - Regla de negocio: Coincidir en el nombre pesa más que en la descripción
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "Keyboard", hits[0].Name)
+	assert.Equal(t, 11.0, hits[0].Score)
+	assert.Equal(t, 1.0, hits[1].Score)
+}
+
+func TestMemoryRepository_Search_IgnoresPunctuationAndNegatedTerms(t *testing.T) {
+	// Arrange
+	repo, _ := memoryCatalog(t)
+
+	// Act
+	hits, err := repo.Search(context.Background(), "Keyboard, -mouse", 0, 10, false)
+
+	// Assert - Regla de negocio: La búsqueda en memoria interpreta la consulta igual que MongoDB
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "Keyboard", hits[0].Name)
+	assert.Equal(t, 11.0, hits[0].Score)
+}
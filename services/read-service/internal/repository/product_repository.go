@@ -2,21 +2,51 @@ package repository
 
 import (
 	"context"
+	"regexp"
+	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/model"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type Product struct {
-	ID          interface{} `bson:"_id,omitempty" json:"_id"`
-	Name        string      `bson:"name" json:"name"`
-	Description int         `bson:"description" json:"description"`
-	Price       float64     `bson:"price" json:"price"`
-	Stock       int         `bson:"stock" json:"stock"`
+type ProductFilter struct {
+	MinPrice   *float64
+	MaxPrice   *float64
+	MinStock   *int
+	NamePrefix string
+	// IncludeDeleted also matches soft-deleted products.
+	IncludeDeleted bool
+}
+
+// Cursor marks the last document of a page: its value for the sort field
+// and its _id, which breaks ties between equal sort values.
+type Cursor struct {
+	Value any
+	ID    any
+}
+
+type ListOptions struct {
+	Filter    ProductFilter
+	SortField string
+	SortDesc  bool
+	Limit     int64
+	Skip      int64
+	After     *Cursor
+}
+
+type SearchHit struct {
+	model.Product `bson:",inline"`
+	Score         float64 `bson:"score" json:"score"`
 }
 
 type ProductRepositoryInterface interface {
-	FindAll(ctx context.Context) ([]Product, error)
+	FindAll(ctx context.Context) ([]model.Product, error)
+	FindByID(ctx context.Context, id any, includeDeleted bool) (*model.Product, error)
+	FindPage(ctx context.Context, opts ListOptions) ([]model.Product, error)
+	Count(ctx context.Context, filter ProductFilter) (int64, error)
+	Search(ctx context.Context, query string, minScore float64, limit int64, includeDeleted bool) ([]SearchHit, error)
 }
 
 type ProductRepository struct {
@@ -29,21 +59,148 @@ func NewProductRepository(db *mongo.Database) *ProductRepository {
 	}
 }
 
-func (r *ProductRepository) FindAll(ctx context.Context) ([]Product, error) {
-	cursor, err := r.collection.Find(ctx, bson.D{})
+func (r *ProductRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+		Options: options.Index().
+			SetName("products_text").
+			SetWeights(bson.D{{Key: "name", Value: 10}, {Key: "description", Value: 1}}),
+	})
+	return err
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context) ([]model.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	cursor, err := r.collection.Find(ctx, model.NotDeleted())
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var products []Product
+	var products []model.Product
 	if err := cursor.All(ctx, &products); err != nil {
 		return nil, err
 	}
 
 	if products == nil {
-		products = []Product{}
+		products = []model.Product{}
+	}
+
+	return products, nil
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id any, includeDeleted bool) (*model.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	filter := bson.M{"_id": id}
+	if !includeDeleted {
+		filter[model.DeletedAtField] = nil
+	}
+	var product model.Product
+	if err := r.collection.FindOne(ctx, filter).Decode(&product); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *ProductRepository) FindPage(ctx context.Context, opts ListOptions) ([]model.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	dir := 1
+	cmp := "$gt"
+	if opts.SortDesc {
+		dir = -1
+		cmp = "$lt"
+	}
+
+	filter := buildFilter(opts.Filter)
+	if opts.After != nil {
+		var after bson.M
+		if opts.SortField == "_id" {
+			after = bson.M{"_id": bson.M{cmp: opts.After.ID}}
+		} else {
+			after = bson.M{"$or": bson.A{
+				bson.M{opts.SortField: bson.M{cmp: opts.After.Value}},
+				bson.M{opts.SortField: opts.After.Value, "_id": bson.M{cmp: opts.After.ID}},
+			}}
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	sort := bson.D{{Key: "_id", Value: dir}}
+	if opts.SortField != "_id" {
+		sort = bson.D{{Key: opts.SortField, Value: dir}, {Key: "_id", Value: dir}}
+	}
+	findOpts := options.Find().SetSort(sort).SetLimit(opts.Limit).SetSkip(opts.Skip)
+
+	cursor, err := r.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
 
+	products := []model.Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
+
+func (r *ProductRepository) Count(ctx context.Context, filter ProductFilter) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return r.collection.CountDocuments(ctx, buildFilter(filter))
+}
+
+func buildFilter(f ProductFilter) bson.M {
+	filter := bson.M{}
+	if !f.IncludeDeleted {
+		filter = model.NotDeleted()
+	}
+	price := bson.M{}
+	if f.MinPrice != nil {
+		price["$gte"] = *f.MinPrice
+	}
+	if f.MaxPrice != nil {
+		price["$lte"] = *f.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["price"] = price
+	}
+	if f.MinStock != nil {
+		filter["stock"] = bson.M{"$gte": *f.MinStock}
+	}
+	if f.NamePrefix != "" {
+		filter["name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(f.NamePrefix)}
+	}
+	return filter
+}
+
+func (r *ProductRepository) Search(ctx context.Context, query string, minScore float64, limit int64, includeDeleted bool) ([]SearchHit, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	match := bson.M{"$text": bson.M{"$search": query}}
+	if !includeDeleted {
+		match[model.DeletedAtField] = nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "textScore"}}}},
+		{{Key: "$match", Value: bson.M{"score": bson.M{"$gte": minScore}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	hits := []SearchHit{}
+	if err := cursor.All(ctx, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	snippetContext = 30
+	maxSnippets    = 3
+)
+
+// highlight returns up to maxSnippets fragments of text around words that
+// start with one of terms, each match wrapped in <em>. Prefix matching
+// approximates the stemming MongoDB applies to $text queries.
+func highlight(text string, terms []string) []string {
+	matches := matchRanges(text, terms)
+	var snippets []string
+
+	for i := 0; i < len(matches) && len(snippets) < maxSnippets; {
+		start := runeStart(text, matches[i][0]-snippetContext)
+		end := runeStart(text, matches[i][1]+snippetContext)
+
+		var b strings.Builder
+		if start > 0 {
+			b.WriteString("…")
+		}
+		cur := start
+		for ; i < len(matches) && matches[i][0] < end; i++ {
+			if matches[i][1] > end {
+				end = matches[i][1]
+			}
+			b.WriteString(html.EscapeString(text[cur:matches[i][0]]))
+			b.WriteString("<em>")
+			b.WriteString(html.EscapeString(text[matches[i][0]:matches[i][1]]))
+			b.WriteString("</em>")
+			cur = matches[i][1]
+		}
+		b.WriteString(html.EscapeString(text[cur:end]))
+		if end < len(text) {
+			b.WriteString("…")
+		}
+		snippets = append(snippets, b.String())
+	}
+	return snippets
+}
+
+func matchRanges(text string, terms []string) [][2]int {
+	var ranges [][2]int
+	start := -1
+	for i, r := range text + " " {
+		if !isNotWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			word := strings.ToLower(text[start:i])
+			for _, term := range terms {
+				if strings.HasPrefix(word, term) {
+					ranges = append(ranges, [2]int{start, i})
+					break
+				}
+			}
+			start = -1
+		}
+	}
+	return ranges
+}
+
+// runeStart clamps i to text and moves it back to the start of a rune.
+func runeStart(text string, i int) int {
+	if i <= 0 {
+		return 0
+	}
+	if i >= len(text) {
+		return len(text)
+	}
+	for i > 0 && !utf8.RuneStart(text[i]) {
+		i--
+	}
+	return i
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
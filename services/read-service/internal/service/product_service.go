@@ -2,18 +2,313 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
 	"github.com/blandoncj/go-products-api/services/read-service/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrInvalidQuery    = errors.New("invalid query")
+)
+
+var sortFields = map[string]string{
+	"":      "_id",
+	"name":  "name",
+	"price": "price",
+	"stock": "stock",
+}
+
+type ListParams struct {
+	PageSize   int
+	Page       int
+	PageToken  string
+	Sort       string
+	Order      string
+	MinPrice   *float64
+	MaxPrice   *float64
+	MinStock   *int
+	NamePrefix string
+	// IncludeDeleted also lists soft-deleted products.
+	IncludeDeleted bool
+}
+
+type ProductPage struct {
+	Items         []model.Product `json:"items"`
+	TotalCount    int64           `json:"total_count"`
+	NextPageToken string          `json:"next_page_token,omitempty"`
+}
+
+type SearchParams struct {
+	Query          string
+	MinScore       *float64
+	Limit          int
+	IncludeDeleted bool
+}
+
+type SearchResult struct {
+	repository.SearchHit
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
+
+type HistoryPage struct {
+	Items         []audit.Entry `json:"items"`
+	NextPageToken string        `json:"next_page_token,omitempty"`
+}
+
+type pageToken struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value any    `json:"v,omitempty"`
+	ID    string `json:"id"`
+}
+
 type ProductService struct {
 	repo repository.ProductRepositoryInterface
+
+	// SearchMinScore is the relevance score below which search hits are
+	// dropped when the request does not set its own minimum.
+	SearchMinScore float64
+
+	// Audit holds the entries History pages through; nil has none.
+	Audit audit.Store
 }
 
 func NewProductService(repo repository.ProductRepositoryInterface) *ProductService {
 	return &ProductService{repo: repo}
 }
 
-func (s *ProductService) GetAll(ctx context.Context) ([]repository.Product, error) {
+func (s *ProductService) GetAll(ctx context.Context) (_ []model.Product, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetAll")
+	defer tracing.End(span, &err)
+
 	return s.repo.FindAll(ctx)
 }
+
+// GetByID treats a soft-deleted product as not found unless includeDeleted
+// is set.
+func (s *ProductService) GetByID(ctx context.Context, id any, includeDeleted bool) (_ *model.Product, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetByID")
+	defer tracing.End(span, &err)
+
+	product, err := s.repo.FindByID(ctx, id, includeDeleted)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProductNotFound
+	}
+	return product, err
+}
+
+func (s *ProductService) List(ctx context.Context, params ListParams) (_ *ProductPage, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.List")
+	defer tracing.End(span, &err)
+
+	opts, err := listOptions(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// one extra document tells us whether a next page exists
+	limit := opts.Limit
+	opts.Limit++
+	items, err := s.repo.FindPage(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.repo.Count(ctx, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ProductPage{Items: items, TotalCount: total}
+	if int64(len(items)) > limit {
+		page.Items = items[:limit]
+		page.NextPageToken = encodePageToken(opts, page.Items[limit-1])
+	}
+	return page, nil
+}
+
+func (s *ProductService) Search(ctx context.Context, params SearchParams) (_ []SearchResult, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.Search")
+	defer tracing.End(span, &err)
+
+	if params.Query == "" {
+		return nil, fmt.Errorf("%w: q is required", ErrInvalidQuery)
+	}
+	if params.Limit < 0 || params.Limit > MaxPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	limit := int64(DefaultPageSize)
+	if params.Limit > 0 {
+		limit = int64(params.Limit)
+	}
+	minScore := s.SearchMinScore
+	if params.MinScore != nil {
+		if *params.MinScore < 0 {
+			return nil, fmt.Errorf("%w: min_score cannot be negative", ErrInvalidQuery)
+		}
+		minScore = *params.MinScore
+	}
+
+	hits, err := s.repo.Search(ctx, params.Query, minScore, limit, params.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := repository.SearchTerms(params.Query)
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		res := SearchResult{SearchHit: hit, Highlights: map[string][]string{}}
+		if snippets := highlight(hit.Name, terms); len(snippets) > 0 {
+			res.Highlights["name"] = snippets
+		}
+		if snippets := highlight(hit.Description, terms); len(snippets) > 0 {
+			res.Highlights["description"] = snippets
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// History pages through the audit entries of a product, newest first. It
+// also covers products that were deleted or purged since.
+func (s *ProductService) History(ctx context.Context, id primitive.ObjectID, pageSize int, token string) (_ *HistoryPage, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.History")
+	defer tracing.End(span, &err)
+
+	if pageSize < 0 || pageSize > MaxPageSize {
+		return nil, fmt.Errorf("%w: page_size must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	var before primitive.ObjectID
+	if token != "" {
+		if before, err = primitive.ObjectIDFromHex(token); err != nil {
+			return nil, fmt.Errorf("%w: invalid page_token", ErrInvalidQuery)
+		}
+	}
+	page := &HistoryPage{Items: []audit.Entry{}}
+	if s.Audit == nil {
+		return page, nil
+	}
+
+	// one extra entry tells us whether a next page exists
+	entries, err := s.Audit.History(ctx, id, before, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	page.Items = entries
+	if len(entries) > pageSize {
+		page.Items = entries[:pageSize]
+		page.NextPageToken = page.Items[pageSize-1].ID.Hex()
+	}
+	return page, nil
+}
+
+func listOptions(params ListParams) (repository.ListOptions, error) {
+	opts := repository.ListOptions{
+		Filter: repository.ProductFilter{
+			MinPrice:       params.MinPrice,
+			MaxPrice:       params.MaxPrice,
+			MinStock:       params.MinStock,
+			NamePrefix:     params.NamePrefix,
+			IncludeDeleted: params.IncludeDeleted,
+		},
+		Limit: DefaultPageSize,
+	}
+
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		return opts, fmt.Errorf("%w: min_price is greater than max_price", ErrInvalidQuery)
+	}
+
+	field, ok := sortFields[params.Sort]
+	if !ok {
+		return opts, fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, params.Sort)
+	}
+	opts.SortField = field
+
+	switch params.Order {
+	case "", "asc":
+	case "desc":
+		opts.SortDesc = true
+	default:
+		return opts, fmt.Errorf("%w: order must be asc or desc", ErrInvalidQuery)
+	}
+
+	if params.PageSize < 0 || params.PageSize > MaxPageSize {
+		return opts, fmt.Errorf("%w: page_size must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	if params.PageSize > 0 {
+		opts.Limit = int64(params.PageSize)
+	}
+
+	if params.PageToken != "" && params.Page > 0 {
+		return opts, fmt.Errorf("%w: page and page_token are mutually exclusive", ErrInvalidQuery)
+	}
+	if params.Page < 0 {
+		return opts, fmt.Errorf("%w: page must be positive", ErrInvalidQuery)
+	}
+	if params.Page > 1 {
+		opts.Skip = int64(params.Page-1) * opts.Limit
+	}
+
+	if params.PageToken != "" {
+		after, err := decodePageToken(params.PageToken, opts)
+		if err != nil {
+			return opts, err
+		}
+		opts.After = after
+	}
+	return opts, nil
+}
+
+func encodePageToken(opts repository.ListOptions, last model.Product) string {
+	if last.ID.IsZero() {
+		return ""
+	}
+	tok := pageToken{Sort: opts.SortField, Desc: opts.SortDesc, ID: last.ID.Hex()}
+	switch opts.SortField {
+	case "name":
+		tok.Value = last.Name
+	case "price":
+		tok.Value = last.Price
+	case "stock":
+		tok.Value = last.Stock
+	}
+	raw, _ := json.Marshal(tok)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodePageToken(token string, opts repository.ListOptions) (*repository.Cursor, error) {
+	invalid := fmt.Errorf("%w: malformed page_token", ErrInvalidQuery)
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+	var tok pageToken
+	if err := json.Unmarshal(raw, &tok); err != nil {
+		return nil, invalid
+	}
+	if tok.Sort != opts.SortField || tok.Desc != opts.SortDesc {
+		return nil, fmt.Errorf("%w: page_token does not match the requested sort", ErrInvalidQuery)
+	}
+	id, err := primitive.ObjectIDFromHex(tok.ID)
+	if err != nil {
+		return nil, invalid
+	}
+	return &repository.Cursor{Value: tok.Value, ID: id}, nil
+}
@@ -3,20 +3,48 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/model"
 	"github.com/blandoncj/go-products-api/services/read-service/internal/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type MockReadRepository struct {
 	mock.Mock
 }
 
-func (m *MockReadRepository) FindAll(ctx context.Context) ([]repository.Product, error) {
+func (m *MockReadRepository) FindAll(ctx context.Context) ([]model.Product, error) {
 	args := m.Called(ctx)
-	return args.Get(0).([]repository.Product), args.Error(1)
+	return args.Get(0).([]model.Product), args.Error(1)
+}
+
+func (m *MockReadRepository) FindByID(ctx context.Context, id any, includeDeleted bool) (*model.Product, error) {
+	args := m.Called(ctx, id, includeDeleted)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockReadRepository) FindPage(ctx context.Context, opts repository.ListOptions) ([]model.Product, error) {
+	args := m.Called(ctx, opts)
+	return args.Get(0).([]model.Product), args.Error(1)
+}
+
+func (m *MockReadRepository) Search(ctx context.Context, query string, minScore float64, limit int64, includeDeleted bool) ([]repository.SearchHit, error) {
+	args := m.Called(ctx, query, minScore, limit, includeDeleted)
+	return args.Get(0).([]repository.SearchHit), args.Error(1)
+}
+
+func (m *MockReadRepository) Count(ctx context.Context, filter repository.ProductFilter) (int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func TestProductService_GetAll_Success(t *testing.T) {
@@ -25,13 +53,13 @@ func TestProductService_GetAll_Success(t *testing.T) {
 	service := NewProductService(mockRepo)
 	ctx := context.Background()
 
-	expectedProducts := []repository.Product{
-		{Name: "Laptop", Description: 1, Price: 1500.00, Stock: 10},
-		{Name: "Mouse", Description: 2, Price: 25.00, Stock: 50},
-		{Name: "Keyboard", Description: 3, Price: 75.00, Stock: 30},
+	expectedProducts := []model.Product{
+		{Name: "Laptop", Description: "Gaming laptop", Price: 1500.00, Stock: 10},
+		{Name: "Mouse", Description: "Wireless mouse", Price: 25.00, Stock: 50},
+		{Name: "Keyboard", Description: "Mechanical keyboard", Price: 75.00, Stock: 30},
 	}
 
-	mockRepo.On("FindAll", ctx).Return(expectedProducts, nil)
+	mockRepo.On("FindAll", mock.Anything).Return(expectedProducts, nil)
 
 	// Act
 	products, err := service.GetAll(ctx)
@@ -49,7 +77,7 @@ func TestProductService_GetAll_EmptyList(t *testing.T) {
 	service := NewProductService(mockRepo)
 	ctx := context.Background()
 
-	mockRepo.On("FindAll", ctx).Return([]repository.Product{}, nil)
+	mockRepo.On("FindAll", mock.Anything).Return([]model.Product{}, nil)
 
 	// Act
 	products, err := service.GetAll(ctx)
@@ -66,12 +94,12 @@ func TestProductService_GetAll_LowStockProducts(t *testing.T) {
 	service := NewProductService(mockRepo)
 	ctx := context.Background()
 
-	lowStockProducts := []repository.Product{
-		{Name: "Laptop", Description: 1, Price: 1500.00, Stock: 2},
-		{Name: "Mouse", Description: 2, Price: 25.00, Stock: 1},
+	lowStockProducts := []model.Product{
+		{Name: "Laptop", Description: "Gaming laptop", Price: 1500.00, Stock: 2},
+		{Name: "Mouse", Description: "Wireless mouse", Price: 25.00, Stock: 1},
 	}
 
-	mockRepo.On("FindAll", ctx).Return(lowStockProducts, nil)
+	mockRepo.On("FindAll", mock.Anything).Return(lowStockProducts, nil)
 
 	// Act
 	products, err := service.GetAll(ctx)
@@ -93,7 +121,7 @@ func TestProductService_GetAll_DatabaseError(t *testing.T) {
 	service := NewProductService(mockRepo)
 	ctx := context.Background()
 
-	mockRepo.On("FindAll", ctx).Return([]repository.Product{}, errors.New("timeout de conexión"))
+	mockRepo.On("FindAll", mock.Anything).Return([]model.Product{}, errors.New("timeout de conexión"))
 
 	// Act
 	products, err := service.GetAll(ctx)
@@ -103,3 +131,268 @@ func TestProductService_GetAll_DatabaseError(t *testing.T) {
 	assert.Empty(t, products, "No debe retornar productos en caso de error")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestProductService_GetByID_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	expected := &model.Product{ID: productID, Name: "Laptop", Price: 1500.00, Stock: 10}
+
+	mockRepo.On("FindByID", mock.Anything, productID, false).Return(expected, nil)
+
+	// Act
+	product, err := service.GetByID(ctx, productID, false)
+
+	// Assert - Regla de negocio: Debe retornar el producto solicitado
+	assert.NoError(t, err)
+	assert.Equal(t, "Laptop", product.Name)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_GetByID_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("FindByID", mock.Anything, productID, false).Return(nil, mongo.ErrNoDocuments)
+
+	// Act
+	product, err := service.GetByID(ctx, productID, false)
+
+	// Assert - Regla de negocio: Producto inexistente debe reportarse como no encontrado
+	assert.ErrorIs(t, err, ErrProductNotFound)
+	assert.Nil(t, product)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_GetByID_IncludeDeleted(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+	deletedAt := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+
+	mockRepo.On("FindByID", mock.Anything, productID, true).Return(&model.Product{ID: productID, DeletedAt: &deletedAt}, nil)
+
+	// Act
+	product, err := service.GetByID(ctx, productID, true)
+
+	// Assert - Regla de negocio: Un producto eliminado sigue visible al pedirlo explícitamente
+	assert.NoError(t, err)
+	assert.Equal(t, &deletedAt, product.DeletedAt)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_GetByID_DatabaseError(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("FindByID", mock.Anything, productID, false).Return(nil, errors.New("timeout de conexión"))
+
+	// Act
+	product, err := service.GetByID(ctx, productID, false)
+
+	// Assert - Regla de negocio: Errores de BD deben propagarse
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrProductNotFound)
+	assert.Nil(t, product)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_List_FirstPage(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	minPrice := 10.0
+
+	items := []model.Product{
+		{ID: primitive.NewObjectID(), Name: "Keyboard", Price: 75.00},
+		{ID: primitive.NewObjectID(), Name: "Laptop", Price: 1500.00},
+		{ID: primitive.NewObjectID(), Name: "Mouse", Price: 25.00},
+	}
+	filter := repository.ProductFilter{MinPrice: &minPrice}
+	opts := repository.ListOptions{Filter: filter, SortField: "name", Limit: 3}
+
+	mockRepo.On("FindPage", mock.Anything, opts).Return(items, nil)
+	mockRepo.On("Count", mock.Anything, filter).Return(int64(5), nil)
+
+	// Act
+	page, err := service.List(ctx, ListParams{PageSize: 2, Sort: "name", MinPrice: &minPrice})
+
+	// Assert - Regla de negocio: Una página llena debe incluir token de siguiente página
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, int64(5), page.TotalCount)
+	assert.NotEmpty(t, page.NextPageToken)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_List_IncludeDeleted(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+
+	filter := repository.ProductFilter{IncludeDeleted: true}
+	opts := repository.ListOptions{Filter: filter, SortField: "_id", Limit: DefaultPageSize + 1}
+
+	mockRepo.On("FindPage", mock.Anything, opts).Return([]model.Product{}, nil)
+	mockRepo.On("Count", mock.Anything, filter).Return(int64(0), nil)
+
+	// Act
+	_, err := service.List(ctx, ListParams{IncludeDeleted: true})
+
+	// Assert - Regla de negocio: Los productos eliminados solo se listan si se piden explícitamente
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_List_FollowsPageToken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	lastID := primitive.NewObjectID()
+
+	token := encodePageToken(
+		repository.ListOptions{SortField: "price", SortDesc: true},
+		model.Product{ID: lastID, Price: 25.00},
+	)
+	opts := repository.ListOptions{
+		SortField: "price",
+		SortDesc:  true,
+		Limit:     DefaultPageSize + 1,
+		After:     &repository.Cursor{Value: 25.00, ID: lastID},
+	}
+
+	mockRepo.On("FindPage", mock.Anything, opts).Return([]model.Product{{ID: primitive.NewObjectID(), Price: 10.00}}, nil)
+	mockRepo.On("Count", mock.Anything, repository.ProductFilter{}).Return(int64(3), nil)
+
+	// Act
+	page, err := service.List(ctx, ListParams{PageToken: token, Sort: "price", Order: "desc"})
+
+	// Assert - Regla de negocio: La última página no tiene token siguiente
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextPageToken)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_List_InvalidParams(t *testing.T) {
+	minPrice, maxPrice := 100.0, 10.0
+	cases := map[string]ListParams{
+		"sort field":   {Sort: "description"},
+		"order":        {Order: "sideways"},
+		"page size":    {PageSize: MaxPageSize + 1},
+		"price range":  {MinPrice: &minPrice, MaxPrice: &maxPrice},
+		"page token":   {PageToken: "not-a-token"},
+		"page + token": {Page: 2, PageToken: "abc"},
+	}
+
+	for name, params := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockReadRepository)
+			service := NewProductService(mockRepo)
+
+			_, err := service.List(context.Background(), params)
+
+			// Regla de negocio: Parámetros inválidos no deben llegar a la BD
+			assert.ErrorIs(t, err, ErrInvalidQuery)
+			mockRepo.AssertNotCalled(t, "FindPage", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestProductService_List_DatabaseError(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindPage", mock.Anything, mock.Anything).Return([]model.Product{}, errors.New("timeout de conexión"))
+
+	// Act
+	page, err := service.List(ctx, ListParams{})
+
+	// Assert - Regla de negocio: Errores de BD deben propagarse
+	assert.Error(t, err)
+	assert.Nil(t, page)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_Search_RankedWithHighlights(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	service.SearchMinScore = 0.5
+	ctx := context.Background()
+
+	hits := []repository.SearchHit{
+		{Product: model.Product{Name: "Gaming Laptop"}, Score: 11.5},
+		{Product: model.Product{Name: "Laptops & <Tablets>"}, Score: 6.2},
+	}
+
+	mockRepo.On("Search", mock.Anything, "laptop", 0.5, int64(DefaultPageSize), false).Return(hits, nil)
+
+	// Act
+	results, err := service.Search(ctx, SearchParams{Query: "laptop"})
+
+	// Assert - Regla de negocio: Los resultados resaltan los términos buscados
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, []string{"Gaming <em>Laptop</em>"}, results[0].Highlights["name"])
+	assert.Equal(t, []string{"<em>Laptops</em> &amp; &lt;Tablets&gt;"}, results[1].Highlights["name"])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_Search_MinScoreOverride(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+	service.SearchMinScore = 0.5
+	ctx := context.Background()
+	minScore := 2.0
+
+	mockRepo.On("Search", mock.Anything, "mouse", 2.0, int64(5), false).Return([]repository.SearchHit{}, nil)
+
+	// Act
+	results, err := service.Search(ctx, SearchParams{Query: "mouse", MinScore: &minScore, Limit: 5})
+
+	// Assert - Regla de negocio: El puntaje mínimo de la petición prevalece
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_Search_EmptyQuery(t *testing.T) {
+	mockRepo := new(MockReadRepository)
+	service := NewProductService(mockRepo)
+
+	_, err := service.Search(context.Background(), SearchParams{})
+
+	// Regla de negocio: Una búsqueda requiere términos
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	mockRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHighlight_LongTextSnippets(t *testing.T) {
+	text := "A sturdy bag that fits any laptop up to fifteen inches, with a padded laptop sleeve and room for chargers, cables and a mouse."
+
+	snippets := highlight(text, repository.SearchTerms("laptop -bag"))
+
+	assert.Len(t, snippets, 2)
+	assert.Contains(t, snippets[0], "<em>laptop</em> up to")
+	assert.NotContains(t, snippets[0], "<em>bag</em>")
+	assert.True(t, strings.HasPrefix(snippets[1], "…"))
+	assert.Contains(t, snippets[1], "padded <em>laptop</em> sleeve")
+}
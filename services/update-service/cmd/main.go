@@ -1,11 +1,28 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/apikey"
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/auth"
+	"github.com/blandoncj/go-products-api/pkg/database"
+	"github.com/blandoncj/go-products-api/pkg/health"
+	"github.com/blandoncj/go-products-api/pkg/logging"
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/ratelimit"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
 	"github.com/blandoncj/go-products-api/services/update-service/internal/controller"
+	"github.com/blandoncj/go-products-api/services/update-service/internal/repository"
+	"github.com/blandoncj/go-products-api/services/update-service/internal/service"
 )
 
 func main() {
@@ -13,7 +30,92 @@ func main() {
 	if port == "" {
 		port = "8083"
 	}
-	handler := controller.NewHandler()
-	log.Printf("Update service listening on :%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, handler))
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		if err := health.Probe(context.Background(), "http://127.0.0.1:"+port+"/readyz"); err != nil {
+			log.Fatalf("update service: %v", err)
+		}
+		return
+	}
+
+	logger := logging.New("update-service")
+	slog.SetDefault(logger)
+
+	cfg, err := server.LoadConfig(":" + port)
+	if err != nil {
+		log.Fatalf("update service: %v", err)
+	}
+	closeTracing, err := tracing.Setup(context.Background(), "update-service")
+	if err != nil {
+		log.Fatalf("update service: %v", err)
+	}
+	verifier, err := auth.FromEnv()
+	if err != nil {
+		log.Fatalf("update service: %v", err)
+	}
+	if verifier == nil {
+		log.Printf("Update service running without authentication")
+	}
+	limiter, err := ratelimit.FromEnv()
+	if err != nil {
+		log.Fatalf("update service: %v", err)
+	}
+	m := metrics.New("update-service")
+	repo, stores, checks, closeRepo, err := newRepository(m)
+	if err != nil {
+		log.Fatalf("update service: %v", err)
+	}
+	keyManager := apikey.NewManager(stores.keys)
+	if verifier != nil {
+		verifier.APIKeys = keyManager
+	}
+	repo = repository.NewInstrumentedRepository(repo, m)
+	svc := service.NewProductService(repo)
+	svc.Audit = audit.NewRecorder(stores.audit)
+	svc.Revisions = repository.NewInstrumentedRevisionRepository(stores.revisions, m)
+	handler := http.NewServeMux()
+	health.NewChecker("update-service", checks...).Register(handler)
+	handler.Handle("/metrics", m.Handler())
+	handler.Handle("/", auth.Require(verifier, auth.OpUpdate)(limiter.Middleware(controller.NewHandler(svc))))
+	log.Printf("Update service listening on %s", cfg.Addr)
+	root := tracing.Middleware(logging.Middleware(logger)(m.Middleware(handler)))
+	if err := server.Run(context.Background(), cfg, root, closeRepo, closeTracing); err != nil {
+		log.Fatalf("update service: %v", err)
+	}
+	log.Printf("Update service stopped")
+}
+
+func newRepository(m *metrics.Metrics) (repository.ProductRepositoryInterface, storeSet, []health.Check, server.Closer, error) {
+	memory, err := memstore.Enabled()
+	if err != nil {
+		return nil, storeSet{}, nil, nil, err
+	}
+	if memory {
+		store, err := memstore.FromEnv()
+		if err != nil {
+			return nil, storeSet{}, nil, nil, err
+		}
+		log.Printf("Update service using in-memory storage")
+		return repository.NewMemoryRepository(store), storeSet{keys: apikey.NewSharedMemoryStore(store.File()), audit: audit.NewSharedMemoryStore(store.File()), revisions: repository.NewMemoryRevisionRepository()}, nil, server.NopCloser, nil
+	}
+
+	db, err := database.Open(context.Background(), m.MongoOption(), tracing.MongoOption())
+	if err != nil {
+		return nil, storeSet{}, nil, nil, err
+	}
+	revisions := repository.NewRevisionRepository(db)
+	ctxIdx, cancelIdx := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelIdx()
+	if err := revisions.EnsureIndexes(ctxIdx); err != nil {
+		_ = db.Client().Disconnect(context.Background())
+		return nil, storeSet{}, nil, nil, fmt.Errorf("create indexes: %w", err)
+	}
+	stores := storeSet{keys: apikey.NewMongoStore(db), audit: audit.NewMongoStore(db), revisions: revisions}
+	return repository.NewUpdateRepository(db), stores, []health.Check{database.HealthCheck(db)}, db.Client().Disconnect, nil
+}
+
+// storeSet holds the stores the service keeps next to its products.
+type storeSet struct {
+	keys      apikey.Store
+	audit     audit.Store
+	revisions repository.RevisionRepositoryInterface
 }
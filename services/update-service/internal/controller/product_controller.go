@@ -1,53 +1,29 @@
 package controller
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
-	"os"
-	"time"
+	"strconv"
+	"strings"
 
-	"github.com/blandoncj/go-products-api/services/update-service/internal/repository"
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/pkg/server"
+	"github.com/blandoncj/go-products-api/pkg/validation"
 	"github.com/blandoncj/go-products-api/services/update-service/internal/service"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type ReqUpdate struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+type updateResponse struct {
+	Status    string         `json:"status"`
+	Unchanged bool           `json:"unchanged"`
+	Product   *model.Product `json:"product"`
 }
 
-func NewHandler() http.Handler {
-	user := os.Getenv("MONGO_ROOT_USERNAME")
-	pass := os.Getenv("MONGO_ROOT_PASSWORD")
-	host := os.Getenv("MONGO_HOST")
-	port := os.Getenv("MONGO_PORT")
-	if port == "" {
-		port = "27017"
-	}
-	dbname := os.Getenv("MONGO_DB")
-
-	mongoURI := fmt.Sprintf("mongodb://%s:%s@%s:%s/?authSource=admin", user, pass, host, port)
-	clientOpts := options.Client().ApplyURI(mongoURI)
-	ctxConn, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	client, err := mongo.Connect(ctxConn, clientOpts)
-	if err != nil {
-		panic(err)
-	}
-	ctxPing, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelPing()
-	if err := client.Ping(ctxPing, nil); err != nil {
-		panic(err)
-	}
-
-	db := client.Database(dbname)
-	repo := repository.NewUpdateRepository(db)
-	svc := service.NewProductService(repo)
-
+func NewHandler(svc *service.ProductService) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -56,33 +32,225 @@ func NewHandler() http.Handler {
 	})
 
 	mux.HandleFunc("/products/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPut {
+		idHex := r.URL.Path[len("/products/"):]
+		if idHex, ok := strings.CutSuffix(idHex, ":rollback"); ok {
+			rollbackProduct(w, r, svc, idHex)
+			return
+		}
+		if idHex, sub, ok := strings.Cut(idHex, "/"); ok {
+			revisions(w, r, svc, idHex, sub)
+			return
+		}
+		if r.Method != http.MethodPut && r.Method != http.MethodPatch {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		idHex := r.URL.Path[len("/products/"):]
 
 		objID, err := primitive.ObjectIDFromHex(idHex)
-		var id interface{}
 		if err != nil {
-			http.Error(w, "invalid id format", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, "invalid id format")
 			return
-		} else {
-			id = objID
 		}
 
-		var payload ReqUpdate
-		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-			http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
+		ifMatch, err := model.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid If-Match header")
 			return
 		}
-		if err := svc.UpdateProduct(r.Context(), id, payload.Name, payload.Description); err != nil {
-			http.Error(w, "update error: "+err.Error(), http.StatusInternalServerError)
+
+		var updated *model.Product
+		switch r.Method {
+		case http.MethodPut:
+			dec := json.NewDecoder(r.Body)
+			dec.DisallowUnknownFields()
+			var product model.Product
+			if err := dec.Decode(&product); err != nil {
+				writeError(w, server.BodyErrorStatus(err), "invalid json: "+err.Error())
+				return
+			}
+			if !product.ID.IsZero() && product.ID != objID {
+				writeError(w, http.StatusBadRequest, "body id does not match path id")
+				return
+			}
+			updated, err = svc.ReplaceProduct(r.Context(), objID, ifMatch, product)
+		case http.MethodPatch:
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, "content type must be application/merge-patch+json")
+				return
+			}
+			patch, readErr := io.ReadAll(r.Body)
+			if readErr != nil {
+				writeError(w, server.BodyErrorStatus(readErr), "invalid body: "+readErr.Error())
+				return
+			}
+			updated, err = svc.PatchProduct(r.Context(), objID, ifMatch, patch)
+		}
+
+		writeUpdate(w, updated, err, "updated")
+	})
+
+	mux.HandleFunc("/products:bulk", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(`{"status":"updated"}`))
+		ordered, err := bulk.ParseOrdered(r.URL.Query().Get("ordered"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var items []service.BulkPatchItem
+		if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+			writeError(w, server.BodyErrorStatus(err), "invalid json: "+err.Error())
+			return
+		}
+		res, err := svc.PatchMany(r.Context(), items, ordered)
+		if errors.Is(err, bulk.ErrTooManyItems) {
+			writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "update error: "+err.Error())
+			return
+		}
+		status := http.StatusOK
+		if res.HasFailures() {
+			status = http.StatusMultiStatus
+		}
+		writeJSON(w, status, res)
 	})
 
 	return mux
 }
+
+// writeUpdate answers a replace, patch or rollback with the stored product
+// or the error that stopped the write.
+func writeUpdate(w http.ResponseWriter, updated *model.Product, err error, status string) {
+	unchanged := errors.Is(err, service.ErrProductUnchanged)
+	var verrs validation.Errors
+	switch {
+	case unchanged:
+	case errors.As(err, &verrs):
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "validation failed", "fields": verrs})
+		return
+	case errors.Is(err, service.ErrInvalidPatch):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrRevisionNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, service.ErrVersionMismatch):
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "update error: "+err.Error())
+		return
+	}
+
+	resp := updateResponse{Status: status, Unchanged: unchanged, Product: updated}
+	if unchanged {
+		resp.Status = "unchanged"
+	}
+	w.Header().Set("ETag", model.ETag(updated.Version))
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func rollbackProduct(w http.ResponseWriter, r *http.Request, svc *service.ProductService, idHex string) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	objID, err := primitive.ObjectIDFromHex(idHex)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id format")
+		return
+	}
+	ifMatch, err := model.ParseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid If-Match header")
+		return
+	}
+	var body struct {
+		Version *int64 `json:"version"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		writeError(w, server.BodyErrorStatus(err), "invalid json: "+err.Error())
+		return
+	}
+	if body.Version == nil {
+		writeError(w, http.StatusBadRequest, "version is required")
+		return
+	}
+	updated, err := svc.RollbackProduct(r.Context(), objID, ifMatch, *body.Version)
+	writeUpdate(w, updated, err, "rolled_back")
+}
+
+// revisions serves /products/{id}/revisions and
+// /products/{id}/revisions/{version}.
+func revisions(w http.ResponseWriter, r *http.Request, svc *service.ProductService, idHex, sub string) {
+	sub, versionText, one := strings.Cut(sub, "/")
+	if sub != "revisions" || (one && (versionText == "" || strings.Contains(versionText, "/"))) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	objID, err := primitive.ObjectIDFromHex(idHex)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid id format")
+		return
+	}
+
+	if one {
+		version, err := strconv.ParseInt(versionText, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid version")
+			return
+		}
+		rev, err := svc.GetRevision(r.Context(), objID, version)
+		if errors.Is(err, service.ErrRevisionNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "error reading revision: "+err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, rev)
+		return
+	}
+
+	q := r.URL.Query()
+	pageSize := 0
+	if v := q.Get("page_size"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid page_size: "+strconv.Quote(v))
+			return
+		}
+	}
+	page, err := svc.ListRevisions(r.Context(), objID, pageSize, q.Get("page_token"))
+	if errors.Is(err, service.ErrInvalidQuery) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "error reading revisions: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
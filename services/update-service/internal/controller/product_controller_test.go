@@ -0,0 +1,374 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/services/update-service/internal/repository"
+	"github.com/blandoncj/go-products-api/services/update-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeRepository keeps products in a map and applies the same version
+// checks as the Mongo repository. Setting err makes every call fail.
+type fakeRepository struct {
+	mu       sync.Mutex
+	products map[primitive.ObjectID]model.Product
+	err      error
+}
+
+func newFakeRepository(products ...model.Product) *fakeRepository {
+	f := &fakeRepository{products: map[primitive.ObjectID]model.Product{}}
+	for _, p := range products {
+		f.products[p.ID] = p
+	}
+	return f
+}
+
+func (f *fakeRepository) UpdateByID(ctx context.Context, id any, version int64, update bson.M) (*mongo.UpdateResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	p, ok := f.live(id)
+	if !ok || p.Version != version {
+		return &mongo.UpdateResult{}, nil
+	}
+	p = applySet(p, update)
+	p.Version++
+	f.products[p.ID] = p
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (f *fakeRepository) ReplaceByID(ctx context.Context, id any, version int64, product model.Product) (*mongo.UpdateResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	p, ok := f.live(id)
+	if !ok || p.Version != version {
+		return &mongo.UpdateResult{}, nil
+	}
+	product.ID = p.ID
+	f.products[p.ID] = product
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+func (f *fakeRepository) FindByID(ctx context.Context, id any) (*model.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	p, ok := f.live(id)
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &p, nil
+}
+
+func (f *fakeRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]model.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	found := map[primitive.ObjectID]model.Product{}
+	for _, id := range ids {
+		if p, ok := f.live(id); ok {
+			found[id] = p
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeRepository) live(id any) (model.Product, bool) {
+	p, ok := f.products[id.(primitive.ObjectID)]
+	return p, ok && p.DeletedAt == nil
+}
+
+// applySet applies a $set document the way MongoDB would, by going
+// through the BSON form of the product.
+func applySet(p model.Product, update bson.M) model.Product {
+	raw, _ := bson.Marshal(p)
+	var doc bson.M
+	_ = bson.Unmarshal(raw, &doc)
+	for k, v := range update {
+		doc[k] = v
+	}
+	raw, _ = bson.Marshal(doc)
+	var out model.Product
+	_ = bson.Unmarshal(raw, &out)
+	return out
+}
+
+func serve(repo *fakeRepository, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	handler := NewHandler(service.NewProductService(repo))
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func laptop() model.Product {
+	return model.Product{ID: primitive.NewObjectID(), Name: "Laptop", Description: "Gaming", Price: 1500, Stock: 10, Version: 2}
+}
+
+var mergePatch = http.Header{"Content-Type": {"application/merge-patch+json"}}
+
+func TestHandler_Health(t *testing.T) {
+	rec := serve(newFakeRepository(), http.MethodGet, "/health", "", nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandler_Replace(t *testing.T) {
+	// Arrange
+	product := laptop()
+	repo := newFakeRepository(product)
+
+	// Act
+	rec := serve(repo, http.MethodPut, "/products/"+product.ID.Hex(), `{"name":"Laptop Pro","price":1800,"stock":5}`, nil)
+
+	// Assert - Regla de negocio: Reemplazar sustituye el documento completo y sube la versión
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+	stored := repo.products[product.ID]
+	assert.Equal(t, "Laptop Pro", stored.Name)
+	assert.Empty(t, stored.Description, "Los campos omitidos vuelven a su valor cero")
+	assert.Equal(t, int64(3), stored.Version)
+}
+
+func TestHandler_Patch(t *testing.T) {
+	// Arrange
+	product := laptop()
+	repo := newFakeRepository(product)
+
+	// Act
+	rec := serve(repo, http.MethodPatch, "/products/"+product.ID.Hex(), `{"price":1200,"description":null}`, mergePatch)
+
+	// Assert - Regla de negocio: Un merge patch solo cambia los campos enviados
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp updateResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "updated", resp.Status)
+	assert.Equal(t, 1200.0, resp.Product.Price)
+	assert.Empty(t, resp.Product.Description)
+	assert.Equal(t, "Laptop", resp.Product.Name)
+}
+
+func TestHandler_Patch_Unchanged(t *testing.T) {
+	// Arrange
+	product := laptop()
+	repo := newFakeRepository(product)
+
+	// Act
+	rec := serve(repo, http.MethodPatch, "/products/"+product.ID.Hex(), `{"price":1500}`, mergePatch)
+
+	// Assert - Regla de negocio: Una escritura sin cambios responde 200 y conserva la versión
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"unchanged","unchanged":true,"product":{"id":"`+product.ID.Hex()+`","name":"Laptop","description":"Gaming","price":1500,"stock":10,"version":2}}`, rec.Body.String())
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+}
+
+func TestHandler_IfMatch(t *testing.T) {
+	// Arrange
+	product := laptop()
+	repo := newFakeRepository(product)
+	target := "/products/" + product.ID.Hex()
+
+	// Act
+	stale := serve(repo, http.MethodPatch, target, `{"stock":1}`, http.Header{"Content-Type": {"application/json"}, "If-Match": {`"1"`}})
+	fresh := serve(repo, http.MethodPatch, target, `{"stock":1}`, http.Header{"Content-Type": {"application/json"}, "If-Match": {`"2"`}})
+
+	// Assert - Regla de negocio: Solo se escribe sobre la versión que el cliente leyó
+	assert.Equal(t, http.StatusPreconditionFailed, stale.Code)
+	assert.Equal(t, http.StatusOK, fresh.Code)
+	assert.Equal(t, 1, repo.products[product.ID].Stock)
+}
+
+func TestHandler_Update_Errors(t *testing.T) {
+	product := laptop()
+	target := "/products/" + product.ID.Hex()
+	missing := "/products/" + primitive.NewObjectID().Hex()
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		header http.Header
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodPost, target, `{}`, nil, nil, http.StatusMethodNotAllowed},
+		{"id invalido", http.MethodPut, "/products/abc", `{}`, nil, nil, http.StatusBadRequest},
+		{"if-match invalido", http.MethodPut, target, `{"name":"x"}`, http.Header{"If-Match": {"3"}}, nil, http.StatusBadRequest},
+		{"put json invalido", http.MethodPut, target, `{"name":`, nil, nil, http.StatusBadRequest},
+		{"put campo desconocido", http.MethodPut, target, `{"name":"x","color":"red"}`, nil, nil, http.StatusBadRequest},
+		{"put id distinto", http.MethodPut, target, `{"id":"` + primitive.NewObjectID().Hex() + `","name":"x"}`, nil, nil, http.StatusBadRequest},
+		{"put invalido", http.MethodPut, target, `{"name":"","price":1}`, nil, nil, http.StatusUnprocessableEntity},
+		{"put no encontrado", http.MethodPut, missing, `{"name":"x"}`, nil, nil, http.StatusNotFound},
+		{"patch tipo de contenido", http.MethodPatch, target, `{"stock":1}`, http.Header{"Content-Type": {"text/plain"}}, nil, http.StatusUnsupportedMediaType},
+		{"patch invalido", http.MethodPatch, target, `[1,2]`, mergePatch, nil, http.StatusBadRequest},
+		{"patch campo desconocido", http.MethodPatch, target, `{"color":"red"}`, mergePatch, nil, http.StatusBadRequest},
+		{"patch no valida", http.MethodPatch, target, `{"price":-1}`, mergePatch, nil, http.StatusUnprocessableEntity},
+		{"patch no encontrado", http.MethodPatch, missing, `{"stock":1}`, mergePatch, nil, http.StatusNotFound},
+		{"error de base de datos", http.MethodPatch, target, `{"stock":1}`, mergePatch, errors.New("error de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo := newFakeRepository(product)
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, c.target, c.body, c.header)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+			assert.Equal(t, product, repo.products[product.ID], "El producto no debe cambiar")
+		})
+	}
+}
+
+func TestHandler_PatchMany(t *testing.T) {
+	// Arrange
+	product := laptop()
+	repo := newFakeRepository(product)
+	body := `[{"id":"` + product.ID.Hex() + `","patch":{"stock":3}},{"id":"` + primitive.NewObjectID().Hex() + `","patch":{"stock":1}}]`
+
+	// Act
+	rec := serve(repo, http.MethodPatch, "/products:bulk?ordered=false", body, nil)
+
+	// Assert - Regla de negocio: Un lote con fallos responde 207 y aplica los ítems válidos
+	require.Equal(t, http.StatusMultiStatus, rec.Code)
+	var res bulk.Result
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.Equal(t, 1, res.Succeeded)
+	assert.Equal(t, 1, res.Failed)
+	assert.Equal(t, 3, repo.products[product.ID].Stock)
+}
+
+func TestHandler_PatchMany_Errors(t *testing.T) {
+	product := laptop()
+	tooMany := "[" + strings.Repeat(`{"id":"x","patch":{}},`, bulk.MaxItems) + `{"id":"x","patch":{}}]`
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		repo   error
+		status int
+	}{
+		{"metodo no permitido", http.MethodPost, "/products:bulk", "[]", nil, http.StatusMethodNotAllowed},
+		{"ordered invalido", http.MethodPatch, "/products:bulk?ordered=maybe", "[]", nil, http.StatusBadRequest},
+		{"json invalido", http.MethodPatch, "/products:bulk", `{}`, nil, http.StatusBadRequest},
+		{"demasiados items", http.MethodPatch, "/products:bulk", tooMany, nil, http.StatusRequestEntityTooLarge},
+		{"error de base de datos", http.MethodPatch, "/products:bulk", `[{"id":"` + product.ID.Hex() + `","patch":{"stock":1}}]`, errors.New("error de conexión"), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			repo := newFakeRepository(product)
+			repo.err = c.repo
+
+			// Act
+			rec := serve(repo, c.method, c.target, c.body, nil)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+			assert.Equal(t, product, repo.products[product.ID])
+		})
+	}
+}
+
+func TestHandler_RevisionsAndRollback(t *testing.T) {
+	// Arrange
+	product := laptop()
+	repo := newFakeRepository(product)
+	svc := service.NewProductService(repo)
+	svc.Revisions = repository.NewMemoryRevisionRepository()
+	handler := NewHandler(svc)
+	do := func(method, target, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	base := "/products/" + product.ID.Hex()
+
+	// Act
+	do(http.MethodPatch, base, `{"price":1200}`, mergePatch)
+	do(http.MethodPatch, base, `{"name":"Laptop Pro"}`, mergePatch)
+	list := do(http.MethodGet, base+"/revisions?page_size=1", "", nil)
+	one := do(http.MethodGet, base+"/revisions/2", "", nil)
+	rollback := do(http.MethodPost, base+":rollback", `{"version":2}`, http.Header{"If-Match": {`"4"`}})
+
+	// Assert - Regla de negocio: Revertir restaura una revisión anterior como una versión nueva
+	require.Equal(t, http.StatusOK, list.Code)
+	var page service.RevisionPage
+	require.NoError(t, json.Unmarshal(list.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, int64(3), page.Items[0].Version)
+	assert.Equal(t, "3", page.NextPageToken)
+	require.Equal(t, http.StatusOK, one.Code)
+	assert.Contains(t, one.Body.String(), `"price":1500`)
+	require.Equal(t, http.StatusOK, rollback.Code)
+	assert.Equal(t, `"5"`, rollback.Header().Get("ETag"))
+	var resp updateResponse
+	require.NoError(t, json.Unmarshal(rollback.Body.Bytes(), &resp))
+	assert.Equal(t, "rolled_back", resp.Status)
+	assert.Equal(t, "Laptop", resp.Product.Name)
+	assert.Equal(t, 1500.0, resp.Product.Price)
+	assert.Equal(t, int64(5), resp.Product.Version)
+}
+
+func TestHandler_Revisions_Errors(t *testing.T) {
+	base := "/products/" + primitive.NewObjectID().Hex()
+	cases := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"metodo no permitido en revisiones", http.MethodPost, base + "/revisions", "", http.StatusMethodNotAllowed},
+		{"subrecurso desconocido", http.MethodGet, base + "/history", "", http.StatusNotFound},
+		{"version invalida", http.MethodGet, base + "/revisions/x", "", http.StatusBadRequest},
+		{"page_token invalido", http.MethodGet, base + "/revisions?page_token=x", "", http.StatusBadRequest},
+		{"revision inexistente", http.MethodGet, base + "/revisions/1", "", http.StatusNotFound},
+		{"rollback sin version", http.MethodPost, base + ":rollback", `{}`, http.StatusBadRequest},
+		{"rollback con id invalido", http.MethodPost, "/products/abc:rollback", `{"version":1}`, http.StatusBadRequest},
+		{"rollback a revision inexistente", http.MethodPost, base + ":rollback", `{"version":1}`, http.StatusNotFound},
+		{"rollback con metodo no permitido", http.MethodGet, base + ":rollback", "", http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			rec := serve(newFakeRepository(), c.method, c.target, c.body, nil)
+
+			// Assert
+			assert.Equal(t, c.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/metrics"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// InstrumentedRepository records the duration and errors of every call to
+// the repository it wraps.
+type InstrumentedRepository struct {
+	next    ProductRepositoryInterface
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedRepository(next ProductRepositoryInterface, m *metrics.Metrics) *InstrumentedRepository {
+	return &InstrumentedRepository{next: next, metrics: m}
+}
+
+func (r *InstrumentedRepository) UpdateByID(ctx context.Context, id any, version int64, update bson.M) (res *mongo.UpdateResult, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "UpdateByID", time.Now(), &err)
+	return r.next.UpdateByID(ctx, id, version, update)
+}
+
+func (r *InstrumentedRepository) ReplaceByID(ctx context.Context, id any, version int64, product model.Product) (res *mongo.UpdateResult, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "ReplaceByID", time.Now(), &err)
+	return r.next.ReplaceByID(ctx, id, version, product)
+}
+
+func (r *InstrumentedRepository) FindByID(ctx context.Context, id any) (product *model.Product, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "FindByID", time.Now(), &err)
+	return r.next.FindByID(ctx, id)
+}
+
+func (r *InstrumentedRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (_ map[primitive.ObjectID]model.Product, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "FindByIDs", time.Now(), &err)
+	return r.next.FindByIDs(ctx, ids)
+}
+
+// InstrumentedRevisionRepository does for revisions what
+// InstrumentedRepository does for products.
+type InstrumentedRevisionRepository struct {
+	next    RevisionRepositoryInterface
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedRevisionRepository(next RevisionRepositoryInterface, m *metrics.Metrics) *InstrumentedRevisionRepository {
+	return &InstrumentedRevisionRepository{next: next, metrics: m}
+}
+
+func (r *InstrumentedRevisionRepository) SaveRevisions(ctx context.Context, revisions ...Revision) (err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "SaveRevisions", time.Now(), &err)
+	return r.next.SaveRevisions(ctx, revisions...)
+}
+
+func (r *InstrumentedRevisionRepository) FindRevisions(ctx context.Context, productID primitive.ObjectID, before int64, limit int) (_ []Revision, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "FindRevisions", time.Now(), &err)
+	return r.next.FindRevisions(ctx, productID, before, limit)
+}
+
+func (r *InstrumentedRevisionRepository) FindRevision(ctx context.Context, productID primitive.ObjectID, version int64) (_ *Revision, err error) {
+	defer r.metrics.ObserveRepositoryCall(ctx, "FindRevision", time.Now(), &err)
+	return r.next.FindRevision(ctx, productID, version)
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRepository updates products held in a memstore.Store with the
+// same matching rules as UpdateRepository.
+type MemoryRepository struct {
+	store *memstore.Store
+}
+
+func NewMemoryRepository(store *memstore.Store) *MemoryRepository {
+	return &MemoryRepository{store: store}
+}
+
+func (r *MemoryRepository) UpdateByID(ctx context.Context, id any, version int64, update bson.M) (*mongo.UpdateResult, error) {
+	return r.update(id, func(p *model.Product) (bool, error) {
+		if p.Version != version {
+			return false, nil
+		}
+		return true, set(p, update)
+	})
+}
+
+func (r *MemoryRepository) ReplaceByID(ctx context.Context, id any, version int64, product model.Product) (*mongo.UpdateResult, error) {
+	return r.update(id, func(p *model.Product) (bool, error) {
+		if p.Version != version {
+			return false, nil
+		}
+		*p = product
+		return true, nil
+	})
+}
+
+func (r *MemoryRepository) FindByID(ctx context.Context, id any) (*model.Product, error) {
+	oid, _ := id.(primitive.ObjectID)
+	p, ok := r.store.Get(oid)
+	if !ok || p.DeletedAt != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &p, nil
+}
+
+func (r *MemoryRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]model.Product, error) {
+	found := make(map[primitive.ObjectID]model.Product, len(ids))
+	for _, id := range ids {
+		if p, ok := r.store.Get(id); ok && p.DeletedAt == nil {
+			found[id] = p
+		}
+	}
+	return found, nil
+}
+
+// update runs fn against the live product with the given id and stores
+// the result when fn accepts the write.
+func (r *MemoryRepository) update(id any, fn func(p *model.Product) (bool, error)) (*mongo.UpdateResult, error) {
+	oid, _ := id.(primitive.ObjectID)
+	var err error
+	written := r.store.Update(oid, func(p *model.Product) bool {
+		if p.DeletedAt != nil {
+			return false
+		}
+		var ok bool
+		ok, err = fn(p)
+		return ok && err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !written {
+		return &mongo.UpdateResult{}, nil
+	}
+	return &mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil
+}
+
+// set applies update and increments the version, like $set with $inc.
+func set(p *model.Product, update bson.M) error {
+	updated, err := memstore.ApplySet(*p, update)
+	if err != nil {
+		return err
+	}
+	updated.Version++
+	*p = updated
+	return nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/memstore"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func newMemoryRepository(t *testing.T, products ...model.Product) *MemoryRepository {
+	t.Helper()
+	store := memstore.New()
+	for _, p := range products {
+		require.NoError(t, store.Insert(p))
+	}
+	return NewMemoryRepository(store)
+}
+
+func TestMemoryRepository_UpdateByID(t *testing.T) {
+	// Arrange
+	product := model.Product{ID: primitive.NewObjectID(), Name: "Laptop", Price: 1500, Version: 2}
+	repo := newMemoryRepository(t, product)
+	ctx := context.Background()
+
+	// Act
+	res, err := repo.UpdateByID(ctx, product.ID, 2, bson.M{"price": 1200.0})
+	stale, staleErr := repo.UpdateByID(ctx, product.ID, 2, bson.M{"price": 1000.0})
+
+	// Assert - Regla de negocio: Solo se actualiza la versión vigente y la versión aumenta
+	require.NoError(t, err)
+	require.NoError(t, staleErr)
+	assert.Equal(t, int64(1), res.MatchedCount)
+	assert.Equal(t, int64(0), stale.MatchedCount)
+	saved, err := repo.FindByID(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1200.0, saved.Price)
+	assert.Equal(t, int64(3), saved.Version)
+}
+
+func TestMemoryRepository_ReplaceByID(t *testing.T) {
+	// Arrange
+	product := model.Product{ID: primitive.NewObjectID(), Name: "Laptop", Version: 1}
+	repo := newMemoryRepository(t, product)
+	ctx := context.Background()
+
+	// Act
+	res, err := repo.ReplaceByID(ctx, product.ID, 1, model.Product{Name: "Notebook", Version: 2})
+
+	// Assert - Regla de negocio: El reemplazo conserva el ID del producto
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.ModifiedCount)
+	saved, err := repo.FindByID(ctx, product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Notebook", saved.Name)
+	assert.Equal(t, product.ID, saved.ID)
+	assert.Equal(t, int64(2), saved.Version)
+}
+
+func TestMemoryRepository_IgnoresDeleted(t *testing.T) {
+	// Arrange
+	deletedAt := time.Date(2025, 11, 20, 10, 0, 0, 0, time.UTC)
+	product := model.Product{ID: primitive.NewObjectID(), Version: 1, DeletedAt: &deletedAt}
+	repo := newMemoryRepository(t, product)
+	ctx := context.Background()
+
+	// Act
+	res, err := repo.UpdateByID(ctx, product.ID, 1, bson.M{"stock": 1})
+	_, findErr := repo.FindByID(ctx, product.ID)
+	existing, _ := repo.FindByIDs(ctx, []primitive.ObjectID{product.ID})
+
+	// Assert - Regla de negocio: Los productos eliminados no pueden modificarse
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), res.MatchedCount)
+	assert.ErrorIs(t, findErr, mongo.ErrNoDocuments)
+	assert.Empty(t, existing)
+}
+
+func TestMemoryRepository_ConcurrentUpdates(t *testing.T) {
+	// Arrange
+	product := model.Product{ID: primitive.NewObjectID(), Version: 1}
+	repo := newMemoryRepository(t, product)
+	ctx := context.Background()
+
+	// Act
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	matched := int64(0)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := repo.UpdateByID(ctx, product.ID, 1, bson.M{"stock": 5})
+			if err == nil {
+				mu.Lock()
+				matched += res.MatchedCount
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Assert - Regla de negocio: Con la misma versión solo una escritura concurrente gana
+	assert.Equal(t, int64(1), matched)
+}
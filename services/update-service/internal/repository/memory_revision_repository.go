@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRevisionRepository keeps revisions in process memory with the
+// same rules as RevisionRepository.
+type MemoryRevisionRepository struct {
+	mu        sync.Mutex
+	revisions map[primitive.ObjectID]map[int64]Revision
+}
+
+func NewMemoryRevisionRepository() *MemoryRevisionRepository {
+	return &MemoryRevisionRepository{revisions: map[primitive.ObjectID]map[int64]Revision{}}
+}
+
+func (r *MemoryRevisionRepository) SaveRevisions(ctx context.Context, revisions ...Revision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rev := range revisions {
+		versions, ok := r.revisions[rev.ProductID]
+		if !ok {
+			versions = map[int64]Revision{}
+			r.revisions[rev.ProductID] = versions
+		}
+		if _, saved := versions[rev.Version]; !saved {
+			versions[rev.Version] = rev
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRevisionRepository) FindRevisions(ctx context.Context, productID primitive.ObjectID, before int64, limit int) ([]Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	revisions := []Revision{}
+	for version, rev := range r.revisions[productID] {
+		if before <= 0 || version < before {
+			revisions = append(revisions, rev)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Version > revisions[j].Version })
+	if len(revisions) > limit {
+		revisions = revisions[:limit]
+	}
+	return revisions, nil
+}
+
+func (r *MemoryRevisionRepository) FindRevision(ctx context.Context, productID primitive.ObjectID, version int64) (*Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rev, ok := r.revisions[productID][version]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &rev, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMemoryRevisionRepository_KeepsFirstSnapshotPerVersion(t *testing.T) {
+	// Arrange
+	repo := NewMemoryRevisionRepository()
+	ctx := context.Background()
+	id := primitive.NewObjectID()
+
+	// Act
+	require.NoError(t, repo.SaveRevisions(ctx,
+		Revision{ProductID: id, Version: 1, Product: model.Product{ID: id, Name: "Laptop", Version: 1}},
+		Revision{ProductID: id, Version: 2, Product: model.Product{ID: id, Name: "Laptop Pro", Version: 2}},
+	))
+	require.NoError(t, repo.SaveRevisions(ctx, Revision{ProductID: id, Version: 1, Product: model.Product{ID: id, Name: "Otro", Version: 1}}))
+	first, err := repo.FindRevision(ctx, id, 1)
+	_, missingErr := repo.FindRevision(ctx, id, 3)
+
+	// Assert - Regla de negocio: Una versión tiene un único estado y no se sobrescribe
+	require.NoError(t, err)
+	assert.Equal(t, "Laptop", first.Product.Name)
+	assert.ErrorIs(t, missingErr, mongo.ErrNoDocuments)
+}
+
+func TestMemoryRevisionRepository_FindRevisionsNewestFirst(t *testing.T) {
+	// Arrange
+	repo := NewMemoryRevisionRepository()
+	ctx := context.Background()
+	id := primitive.NewObjectID()
+	for v := int64(1); v <= 4; v++ {
+		require.NoError(t, repo.SaveRevisions(ctx, Revision{ProductID: id, Version: v}))
+	}
+	require.NoError(t, repo.SaveRevisions(ctx, Revision{ProductID: primitive.NewObjectID(), Version: 1}))
+
+	// Act
+	page, _ := repo.FindRevisions(ctx, id, 0, 2)
+	rest, _ := repo.FindRevisions(ctx, id, page[1].Version, 10)
+
+	// Assert - Regla de negocio: Las revisiones se listan de la más reciente a la más antigua
+	assert.Equal(t, []int64{4, 3}, versions(page))
+	assert.Equal(t, []int64{2, 1}, versions(rest))
+}
+
+func versions(revisions []Revision) []int64 {
+	out := []int64{}
+	for _, rev := range revisions {
+		out = append(out, rev.Version)
+	}
+	return out
+}
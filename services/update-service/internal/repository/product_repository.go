@@ -4,13 +4,18 @@ import (
 	"context"
 	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/model"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ProductRepositoryInterface interface {
-	UpdateByID(ctx context.Context, id any, update bson.M) (*mongo.UpdateResult, error)
+	UpdateByID(ctx context.Context, id any, version int64, update bson.M) (*mongo.UpdateResult, error)
+	ReplaceByID(ctx context.Context, id any, version int64, product model.Product) (*mongo.UpdateResult, error)
+	FindByID(ctx context.Context, id any) (*model.Product, error)
+	FindByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]model.Product, error)
 }
 
 type UpdateRepository struct {
@@ -21,10 +26,53 @@ func NewUpdateRepository(db *mongo.Database) *UpdateRepository {
 	return &UpdateRepository{collection: db.Collection("products")}
 }
 
-func (r *UpdateRepository) UpdateByID(ctx context.Context, id interface{}, update bson.M) (*mongo.UpdateResult, error) {
+// Soft-deleted products are invisible to every method of the repository.
+//
+// UpdateByID and ReplaceByID only match the product while it is still at
+// version, so a concurrent write makes them match nothing.
+func (r *UpdateRepository) UpdateByID(ctx context.Context, id interface{}, version int64, update bson.M) (*mongo.UpdateResult, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	opts := options.Update().SetUpsert(false)
-	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update}, opts)
+	filter := bson.M{"_id": id, "version": model.VersionFilter(version), model.DeletedAtField: nil}
+	res, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": update, "$inc": bson.M{"version": 1}}, opts)
 	return res, err
 }
+
+func (r *UpdateRepository) ReplaceByID(ctx context.Context, id any, version int64, product model.Product) (*mongo.UpdateResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	opts := options.Replace().SetUpsert(false)
+	filter := bson.M{"_id": id, "version": model.VersionFilter(version), model.DeletedAtField: nil}
+	res, err := r.collection.ReplaceOne(ctx, filter, product, opts)
+	return res, err
+}
+
+func (r *UpdateRepository) FindByID(ctx context.Context, id any) (*model.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var product model.Product
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id, model.DeletedAtField: nil}).Decode(&product); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// FindByIDs returns the listed products that exist, keyed by ID.
+func (r *UpdateRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]model.Product, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, model.DeletedAtField: nil})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var products []model.Product
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	found := make(map[primitive.ObjectID]model.Product, len(products))
+	for _, p := range products {
+		found[p.ID] = p
+	}
+	return found, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const duplicateKeyCode = 11000
+
+// Revision is a product as it was at Version, saved before a write
+// replaced it.
+type Revision struct {
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	Version   int64              `bson:"version" json:"version"`
+	Product   model.Product      `bson:"product" json:"product"`
+	SavedAt   time.Time          `bson:"saved_at" json:"saved_at"`
+}
+
+type RevisionRepositoryInterface interface {
+	// SaveRevisions keeps the revision already saved for a version, since
+	// a product only ever has one state per version.
+	SaveRevisions(ctx context.Context, revisions ...Revision) error
+	// FindRevisions returns up to limit revisions of a product, newest
+	// first, below version before when it is positive.
+	FindRevisions(ctx context.Context, productID primitive.ObjectID, before int64, limit int) ([]Revision, error)
+	FindRevision(ctx context.Context, productID primitive.ObjectID, version int64) (*Revision, error)
+}
+
+type RevisionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRevisionRepository(db *mongo.Database) *RevisionRepository {
+	return &RevisionRepository{collection: db.Collection("revisions")}
+}
+
+// EnsureIndexes creates the unique index that keeps one revision per
+// product version.
+func (r *RevisionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *RevisionRepository) SaveRevisions(ctx context.Context, revisions ...Revision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(revisions))
+	for i, rev := range revisions {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"product_id": rev.ProductID, "version": rev.Version}).
+			SetUpdate(bson.M{"$setOnInsert": bson.M{"product": rev.Product, "saved_at": rev.SavedAt}}).
+			SetUpsert(true)
+	}
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	// two upserts of the same version can race; the loser changes nothing
+	var bwe mongo.BulkWriteException
+	if errors.As(err, &bwe) && bwe.WriteConcernError == nil && onlyDuplicates(bwe.WriteErrors) {
+		return nil
+	}
+	return err
+}
+
+func onlyDuplicates(errs []mongo.BulkWriteError) bool {
+	for _, e := range errs {
+		if e.Code != duplicateKeyCode {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *RevisionRepository) FindRevisions(ctx context.Context, productID primitive.ObjectID, before int64, limit int) ([]Revision, error) {
+	filter := bson.M{"product_id": productID}
+	if before > 0 {
+		filter["version"] = bson.M{"$lt": before}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []Revision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *RevisionRepository) FindRevision(ctx context.Context, productID primitive.ObjectID, version int64) (*Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	var rev Revision
+	if err := r.collection.FindOne(ctx, bson.M{"product_id": productID, "version": version}).Decode(&rev); err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
@@ -1,27 +1,410 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/pkg/tracing"
+	"github.com/blandoncj/go-products-api/pkg/validation"
 	"github.com/blandoncj/go-products-api/services/update-service/internal/repository"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	ErrInvalidPatch     = errors.New("invalid merge patch")
+	ErrInvalidQuery     = errors.New("invalid query")
+	ErrProductNotFound  = errors.New("product not found")
+	ErrProductUnchanged = errors.New("product unchanged")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrVersionMismatch  = errors.New("product version does not match")
+)
+
+type productField struct {
+	index    int
+	bsonName string
+	typ      reflect.Type
+}
+
+// patchableFields maps the JSON name of every model.Product field that a
+// merge patch may touch to its BSON name and Go type. The ID, version and
+// deletion mark are managed by the services.
+var patchableFields = func() map[string]productField {
+	fields := map[string]productField{}
+	t := reflect.TypeOf(model.Product{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		bsonName, _, _ := strings.Cut(f.Tag.Get("bson"), ",")
+		if bsonName == "_id" || bsonName == "version" || bsonName == model.DeletedAtField {
+			continue
+		}
+		fields[jsonName] = productField{index: i, bsonName: bsonName, typ: f.Type}
+	}
+	return fields
+}()
+
 type ProductService struct {
-	repo repository.ProductRepositoryInterface
+	repo  repository.ProductRepositoryInterface
+	Rules *validation.Validator
+	// Audit records every update; nil records nothing.
+	Audit *audit.Recorder
+	// Revisions keeps every version a write replaces, so it can be rolled
+	// back to; nil keeps none.
+	Revisions repository.RevisionRepositoryInterface
+
+	now func() time.Time
+}
+
+type RevisionPage struct {
+	Items         []repository.Revision `json:"items"`
+	NextPageToken string                `json:"next_page_token,omitempty"`
 }
 
 func NewProductService(repo repository.ProductRepositoryInterface) *ProductService {
-	return &ProductService{repo: repo}
+	return &ProductService{repo: repo, Rules: validation.ProductRules(), now: time.Now}
 }
 
-func (s *ProductService) UpdateProduct(ctx context.Context, id interface{}, name string, description string) error {
-	update := bson.M{}
+// ReplaceProduct and PatchProduct return the stored product after the
+// write. When the write would change nothing, the current product is
+// returned together with ErrProductUnchanged and the version is kept.
+//
+// ifMatch lists the versions the caller expects the product to be at; nil
+// skips the check. Either way the write only lands if the product was not
+// changed since it was read, otherwise ErrVersionMismatch is returned.
+func (s *ProductService) ReplaceProduct(ctx context.Context, id interface{}, ifMatch []int64, product model.Product) (_ *model.Product, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.ReplaceProduct")
+	defer tracing.End(span, &err)
+
+	return s.replace(ctx, id, ifMatch, product, audit.ActionUpdate)
+}
+
+// RollbackProduct replaces the product with the revision saved at version,
+// as a new version. ifMatch works as in ReplaceProduct.
+func (s *ProductService) RollbackProduct(ctx context.Context, id primitive.ObjectID, ifMatch []int64, version int64) (_ *model.Product, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.RollbackProduct")
+	defer tracing.End(span, &err)
+
+	rev, err := s.GetRevision(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.replace(ctx, id, ifMatch, rev.Product, audit.ActionRollback)
+}
+
+func (s *ProductService) replace(ctx context.Context, id interface{}, ifMatch []int64, product model.Product, action string) (*model.Product, error) {
+	if err := s.Rules.Validate(validation.ProductValues(product)); err != nil {
+		return nil, err
+	}
+	current, err := s.current(ctx, id, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+	product.ID = current.ID
+	product.Version = current.Version
+	product.DeletedAt = current.DeletedAt
+	if product == *current {
+		return current, ErrProductUnchanged
+	}
+	if err := s.snapshot(ctx, *current); err != nil {
+		return nil, err
+	}
+
+	product.ID = primitive.NilObjectID
+	product.Version = current.Version + 1
+	res, err := s.repo.ReplaceByID(ctx, id, current.Version, product)
+	if err != nil {
+		return nil, err
+	}
+	return s.result(ctx, id, action, current, res)
+}
+
+// PatchProduct applies an RFC 7396 merge patch. A null member clears the
+// field back to its zero value in model.Product.
+func (s *ProductService) PatchProduct(ctx context.Context, id interface{}, ifMatch []int64, patch []byte) (_ *model.Product, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.PatchProduct")
+	defer tracing.End(span, &err)
+
+	update, err := mergePatchUpdate(patch)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Rules.Validate(update); err != nil {
+		return nil, err
+	}
+	current, err := s.current(ctx, id, ifMatch)
+	if err != nil {
+		return nil, err
+	}
+	if applyUpdate(*current, update) == *current {
+		return current, ErrProductUnchanged
+	}
+	if err := s.snapshot(ctx, *current); err != nil {
+		return nil, err
+	}
 
-	if name != "" {
-		update["name"] = name
+	res, err := s.repo.UpdateByID(ctx, id, current.Version, update)
+	if err != nil {
+		return nil, err
+	}
+	return s.result(ctx, id, audit.ActionUpdate, current, res)
+}
+
+type BulkPatchItem struct {
+	ID    string          `json:"id"`
+	Patch json.RawMessage `json:"patch"`
+}
+
+// PatchMany applies a merge patch to each listed product, one conditional
+// write at a time at the version read for the batch, so a product written
+// concurrently fails with ErrVersionMismatch instead of being overwritten.
+// Items with a bad ID, an invalid patch or an unknown product fail without
+// being sent; in ordered mode any failure also stops the batch.
+func (s *ProductService) PatchMany(ctx context.Context, items []BulkPatchItem, ordered bool) (_ *bulk.Result, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.PatchMany")
+	defer tracing.End(span, &err)
+
+	if len(items) > bulk.MaxItems {
+		return nil, bulk.ErrTooManyItems
+	}
+	res := bulk.NewResult(len(items), ordered)
+
+	type op struct {
+		index  int
+		id     primitive.ObjectID
+		update bson.M
+	}
+	var ops []op
+	var ids []primitive.ObjectID
+	for i, item := range items {
+		res.SetID(i, item.ID)
+		id, err := primitive.ObjectIDFromHex(item.ID)
+		if err != nil {
+			err = errors.New("invalid id format")
+		}
+		var update bson.M
+		if err == nil {
+			update, err = mergePatchUpdate(item.Patch)
+		}
+		if err == nil {
+			err = s.Rules.Validate(update)
+		}
+		if err != nil {
+			res.Fail(i, err)
+			if ordered {
+				break
+			}
+			continue
+		}
+		ops = append(ops, op{index: i, id: id, update: update})
+		ids = append(ids, id)
+	}
+	if len(ops) == 0 {
+		return res.Finish(), nil
+	}
+
+	existing, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the writes that matched are audited
+	var updated []audit.Mutation
+	for _, o := range ops {
+		before, ok := existing[o.id]
+		if !ok {
+			res.Fail(o.index, ErrProductNotFound)
+			if ordered {
+				break
+			}
+			continue
+		}
+		if len(o.update) > 0 {
+			if err := s.snapshot(ctx, before); err != nil {
+				res.Fail(o.index, err)
+				if ordered {
+					break
+				}
+				continue
+			}
+			upd, err := s.repo.UpdateByID(ctx, o.id, before.Version, o.update)
+			switch werr, rejected := bulk.WriteError(err); {
+			case rejected:
+				err = werr
+			case err != nil:
+				return nil, errors.Join(err, s.Audit.Record(ctx, updated...))
+			case upd.MatchedCount == 0:
+				err = ErrVersionMismatch
+			}
+			if err != nil {
+				res.Fail(o.index, err)
+				if ordered {
+					break
+				}
+				continue
+			}
+			after := applyUpdate(before, o.update)
+			after.Version++
+			updated = append(updated, audit.Changed(audit.ActionUpdate, &before, &after))
+			existing[o.id] = after
+		}
+		res.Succeed(o.index)
+	}
+	if err := s.Audit.Record(ctx, updated...); err != nil {
+		return nil, err
+	}
+	return res.Finish(), nil
+}
+
+// ListRevisions pages through the saved revisions of a product, newest first.
+// The page token is the version the next page starts below.
+func (s *ProductService) ListRevisions(ctx context.Context, id primitive.ObjectID, pageSize int, token string) (_ *RevisionPage, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.ListRevisions")
+	defer tracing.End(span, &err)
+
+	if pageSize < 0 || pageSize > MaxPageSize {
+		return nil, fmt.Errorf("%w: page_size must be between 1 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	var before int64
+	if token != "" {
+		if before, err = strconv.ParseInt(token, 10, 64); err != nil || before <= 0 {
+			return nil, fmt.Errorf("%w: invalid page_token", ErrInvalidQuery)
+		}
+	}
+	page := &RevisionPage{Items: []repository.Revision{}}
+	if s.Revisions == nil {
+		return page, nil
+	}
+
+	// one extra revision tells us whether a next page exists
+	revisions, err := s.Revisions.FindRevisions(ctx, id, before, pageSize+1)
+	if err != nil {
+		return nil, err
+	}
+	page.Items = revisions
+	if len(revisions) > pageSize {
+		page.Items = revisions[:pageSize]
+		page.NextPageToken = strconv.FormatInt(page.Items[pageSize-1].Version, 10)
+	}
+	return page, nil
+}
+
+// GetRevision returns the product as it was at version.
+func (s *ProductService) GetRevision(ctx context.Context, id primitive.ObjectID, version int64) (_ *repository.Revision, err error) {
+	ctx, span := tracing.Start(ctx, "ProductService.GetRevision")
+	defer tracing.End(span, &err)
+
+	if s.Revisions == nil {
+		return nil, ErrRevisionNotFound
+	}
+	rev, err := s.Revisions.FindRevision(ctx, id, version)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrRevisionNotFound
+	}
+	return rev, err
+}
+
+// snapshot saves products as revisions before a write replaces them. It
+// runs before the write so a version is never lost; saving a version that
+// the write then fails to replace is harmless.
+func (s *ProductService) snapshot(ctx context.Context, products ...model.Product) error {
+	if s.Revisions == nil || len(products) == 0 {
+		return nil
+	}
+	at := s.now().UTC()
+	revisions := make([]repository.Revision, len(products))
+	for i, p := range products {
+		revisions[i] = repository.Revision{ProductID: p.ID, Version: p.Version, Product: p, SavedAt: at}
+	}
+	return s.Revisions.SaveRevisions(ctx, revisions...)
+}
+
+func (s *ProductService) current(ctx context.Context, id interface{}, ifMatch []int64) (*model.Product, error) {
+	product, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if ifMatch != nil && !slices.Contains(ifMatch, product.Version) {
+		return nil, ErrVersionMismatch
+	}
+	return product, nil
+}
+
+// result reads the product back after a write to before and records it.
+func (s *ProductService) result(ctx context.Context, id interface{}, action string, before *model.Product, res *mongo.UpdateResult) (*model.Product, error) {
+	// the version filter did not match: someone wrote since we read
+	if res.MatchedCount == 0 {
+		return nil, ErrVersionMismatch
+	}
+	product, err := s.repo.FindByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Audit.Record(ctx, audit.Changed(action, before, product)); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// applyUpdate returns a copy of p with the fields of a $set document
+// applied, so a patch can be compared against the stored product.
+func applyUpdate(p model.Product, update bson.M) model.Product {
+	v := reflect.ValueOf(&p).Elem()
+	for _, field := range patchableFields {
+		if value, ok := update[field.bsonName]; ok {
+			v.Field(field.index).Set(reflect.ValueOf(value))
+		}
+	}
+	return p
+}
+
+func mergePatchUpdate(patch []byte) (bson.M, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil || members == nil {
+		return nil, fmt.Errorf("%w: patch must be a JSON object", ErrInvalidPatch)
+	}
+
+	update := bson.M{}
+	for name, raw := range members {
+		field, ok := patchableFields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown or read-only field %q", ErrInvalidPatch, name)
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			update[field.bsonName] = reflect.Zero(field.typ).Interface()
+			continue
+		}
+		value := reflect.New(field.typ)
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			return nil, fmt.Errorf("%w: field %q must be a %s", ErrInvalidPatch, name, field.typ)
+		}
+		update[field.bsonName] = value.Elem().Interface()
 	}
-	update["description"] = description
-	_, err := s.repo.UpdateByID(ctx, id, update)
-	return err
+	return update, nil
 }
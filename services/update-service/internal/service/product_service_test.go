@@ -5,6 +5,11 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/blandoncj/go-products-api/pkg/audit"
+	"github.com/blandoncj/go-products-api/pkg/bulk"
+	"github.com/blandoncj/go-products-api/pkg/model"
+	"github.com/blandoncj/go-products-api/pkg/validation"
+	"github.com/blandoncj/go-products-api/services/update-service/internal/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.mongodb.org/mongo-driver/bson"
@@ -16,64 +21,218 @@ type MockUpdateRepository struct {
 	mock.Mock
 }
 
-func (m *MockUpdateRepository) UpdateByID(ctx context.Context, id interface{}, update bson.M) (*mongo.UpdateResult, error) {
-	args := m.Called(ctx, id, update)
+func (m *MockUpdateRepository) UpdateByID(ctx context.Context, id interface{}, version int64, update bson.M) (*mongo.UpdateResult, error) {
+	args := m.Called(ctx, id, version, update)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*mongo.UpdateResult), args.Error(1)
 }
 
-func TestProductService_UpdateProduct_Success(t *testing.T) {
+func (m *MockUpdateRepository) ReplaceByID(ctx context.Context, id any, version int64, product model.Product) (*mongo.UpdateResult, error) {
+	args := m.Called(ctx, id, version, product)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*mongo.UpdateResult), args.Error(1)
+}
+
+func (m *MockUpdateRepository) FindByID(ctx context.Context, id any) (*model.Product, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Product), args.Error(1)
+}
+
+func (m *MockUpdateRepository) FindByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]model.Product, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[primitive.ObjectID]model.Product), args.Error(1)
+}
+
+func TestProductService_ReplaceProduct_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUpdateRepository)
 	service := NewProductService(mockRepo)
 	ctx := context.Background()
 	productID := primitive.NewObjectID()
 
-	newName := "Laptop Pro"
-	newDescription := "Updated description"
+	current := &model.Product{ID: productID, Name: "Laptop", Price: 1500.00, Stock: 10, Version: 3}
+	product := model.Product{Name: "Laptop Pro", Description: "Updated description", Price: 1800.00, Stock: 4}
+	stored := product
+	stored.Version = 4
+	replaced := stored
+	replaced.ID = productID
+
+	mockRepo.On("FindByID", mock.Anything, productID).Return(current, nil).Once()
+	mockRepo.On("ReplaceByID", mock.Anything, productID, int64(3), stored).Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+	mockRepo.On("FindByID", mock.Anything, productID).Return(&replaced, nil).Once()
+
+	// Act
+	updated, err := service.ReplaceProduct(ctx, productID, nil, product)
+
+	// Assert - Regla de negocio: El reemplazo actualiza todos los campos e incrementa la versión
+	assert.NoError(t, err, "El reemplazo debe ser exitoso")
+	assert.Equal(t, &replaced, updated, "Debe retornar el producto actualizado")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_ReplaceProduct_DatabaseError(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("FindByID", mock.Anything, productID).Return(&model.Product{ID: productID, Name: "Mouse", Version: 1}, nil)
+	mockRepo.On("ReplaceByID", mock.Anything, productID, int64(1), mock.Anything).Return(nil, errors.New("fallo de escritura"))
+
+	// Act
+	_, err := service.ReplaceProduct(ctx, productID, nil, model.Product{Name: "Laptop"})
+
+	// Assert - Regla de negocio: Errores de BD deben propagarse
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "escritura")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_ReplaceProduct_ValidationError(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+
+	// Act
+	_, err := service.ReplaceProduct(ctx, primitive.NewObjectID(), nil, model.Product{Name: "", Price: -1})
+
+	// Assert - Regla de negocio: El reemplazo debe cumplir las reglas del producto
+	var verrs validation.Errors
+	assert.True(t, errors.As(err, &verrs))
+	assert.Len(t, verrs, 2)
+	mockRepo.AssertNotCalled(t, "ReplaceByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductService_ReplaceProduct_Unchanged(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+	current := &model.Product{ID: productID, Name: "Laptop", Price: 1500.00, Stock: 10, Version: 2}
+
+	mockRepo.On("FindByID", mock.Anything, productID).Return(current, nil)
+
+	// Act
+	product, err := service.ReplaceProduct(ctx, productID, nil, model.Product{Name: "Laptop", Price: 1500.00, Stock: 10})
+
+	// Assert - Regla de negocio: Un reemplazo idéntico no cambia la versión
+	assert.ErrorIs(t, err, ErrProductUnchanged)
+	assert.Equal(t, current, product)
+	mockRepo.AssertNotCalled(t, "ReplaceByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductService_ReplaceProduct_StaleIfMatch(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("FindByID", mock.Anything, productID).Return(&model.Product{ID: productID, Name: "Laptop", Version: 5}, nil)
+
+	// Act
+	product, err := service.ReplaceProduct(ctx, productID, []int64{4}, model.Product{Name: "Laptop Pro"})
+
+	// Assert - Regla de negocio: Una escritura con versión obsoleta se rechaza
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.Nil(t, product)
+	mockRepo.AssertNotCalled(t, "ReplaceByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductService_PatchProduct_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
 
 	expectedUpdate := bson.M{
-		"name":        newName,
-		"description": newDescription,
+		"name":  "Laptop Pro",
+		"price": 1800.00,
+		"stock": 7,
 	}
 
-	mockRepo.On("UpdateByID", ctx, productID, expectedUpdate).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+	current := &model.Product{ID: productID, Name: "Laptop", Price: 1500.00, Stock: 10, Version: 1}
+	patched := &model.Product{ID: productID, Name: "Laptop Pro", Price: 1800.00, Stock: 7, Version: 2}
+
+	mockRepo.On("FindByID", mock.Anything, productID).Return(current, nil).Once()
+	mockRepo.On("UpdateByID", mock.Anything, productID, int64(1), expectedUpdate).Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+	mockRepo.On("FindByID", mock.Anything, productID).Return(patched, nil).Once()
 
 	// Act
-	err := service.UpdateProduct(ctx, productID, newName, newDescription)
+	updated, err := service.PatchProduct(ctx, productID, []int64{1}, []byte(`{"name":"Laptop Pro","price":1800,"stock":7}`))
 
-	// Assert - Regla de negocio: Actualización exitosa debe modificar el producto
+	// Assert - Regla de negocio: El parche puede modificar cualquier campo del producto
 	assert.NoError(t, err, "La actualización debe ser exitosa")
+	assert.Equal(t, patched, updated, "Debe retornar el producto actualizado")
 	mockRepo.AssertExpectations(t)
 }
 
-func TestProductService_UpdateProduct_PartialUpdate(t *testing.T) {
+func TestProductService_PatchProduct_RecordsAudit(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUpdateRepository)
+	store := audit.NewMemoryStore()
 	service := NewProductService(mockRepo)
+	service.Audit = audit.NewRecorder(store)
 	ctx := context.Background()
 	productID := primitive.NewObjectID()
 
-	// Solo actualizar descripción (nombre vacío)
-	newDescription := "Only description updated"
+	current := &model.Product{ID: productID, Name: "Laptop", Price: 1500.00, Stock: 10, Version: 1}
+	patched := &model.Product{ID: productID, Name: "Laptop", Price: 1500.00, Stock: 7, Version: 2}
+
+	mockRepo.On("FindByID", mock.Anything, productID).Return(current, nil).Once()
+	mockRepo.On("UpdateByID", mock.Anything, productID, int64(1), bson.M{"stock": 7}).Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+	mockRepo.On("FindByID", mock.Anything, productID).Return(patched, nil).Once()
+
+	// Act
+	_, err := service.PatchProduct(ctx, productID, nil, []byte(`{"stock":7}`))
+
+	// Assert - Regla de negocio: La auditoría guarda solo los campos modificados con su valor anterior
+	assert.NoError(t, err)
+	entries, _ := store.History(ctx, productID, primitive.NilObjectID, 10)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, audit.ActionUpdate, entries[0].Action)
+		assert.Equal(t, int64(2), entries[0].Version)
+		assert.Equal(t, []audit.Change{{Field: "stock", Before: 10, After: 7}}, entries[0].Changes)
+	}
+}
+
+func TestProductService_PatchProduct_PartialUpdate(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
 
 	expectedUpdate := bson.M{
-		"description": newDescription,
+		"description": "Only description updated",
 	}
 
-	mockRepo.On("UpdateByID", ctx, productID, expectedUpdate).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+	mockRepo.On("FindByID", mock.Anything, productID).Return(&model.Product{ID: productID, Name: "Laptop"}, nil)
+	mockRepo.On("UpdateByID", mock.Anything, productID, int64(0), expectedUpdate).Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
 
 	// Act
-	err := service.UpdateProduct(ctx, productID, "", newDescription)
+	_, err := service.PatchProduct(ctx, productID, nil, []byte(`{"description":"Only description updated"}`))
 
-	// Assert - Regla de negocio: Actualización parcial es válida (solo descripción)
+	// Assert - Regla de negocio: Los campos ausentes del parche no se modifican
 	assert.NoError(t, err, "Actualización parcial debe ser exitosa")
 	mockRepo.AssertExpectations(t)
 }
 
-func TestProductService_UpdateProduct_EmptyFields(t *testing.T) {
+func TestProductService_PatchProduct_ClearField(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUpdateRepository)
 	service := NewProductService(mockRepo)
@@ -82,41 +241,138 @@ func TestProductService_UpdateProduct_EmptyFields(t *testing.T) {
 
 	expectedUpdate := bson.M{
 		"description": "",
+		"stock":       0,
 	}
 
-	mockRepo.On("UpdateByID", ctx, productID, expectedUpdate).Return(&mongo.UpdateResult{ModifiedCount: 1}, nil)
+	mockRepo.On("FindByID", mock.Anything, productID).Return(&model.Product{ID: productID, Name: "Laptop", Description: "Old", Stock: 3, Version: 7}, nil)
+	mockRepo.On("UpdateByID", mock.Anything, productID, int64(7), expectedUpdate).Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
 
 	// Act
-	err := service.UpdateProduct(ctx, productID, "", "")
+	_, err := service.PatchProduct(ctx, productID, nil, []byte(`{"description":null,"stock":null}`))
 
-	// Assert - Regla de negocio: Se permite actualizar con descripción vacía
-	assert.NoError(t, err, "Actualización con campos vacíos debe funcionar")
+	// Assert - Regla de negocio: null limpia explícitamente el campo
+	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestProductService_UpdateProduct_NotFound(t *testing.T) {
+func TestProductService_PatchProduct_ValidationError(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+
+	// Act
+	_, err := service.PatchProduct(ctx, primitive.NewObjectID(), nil, []byte(`{"name":null,"stock":-3}`))
+
+	// Assert - Regla de negocio: No se puede limpiar el nombre ni dejar stock negativo
+	var verrs validation.Errors
+	assert.True(t, errors.As(err, &verrs))
+	assert.Equal(t, "name", verrs[0].Field)
+	assert.Equal(t, "stock", verrs[1].Field)
+	mockRepo.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductService_PatchProduct_EmptyPatch(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUpdateRepository)
 	service := NewProductService(mockRepo)
 	ctx := context.Background()
 	productID := primitive.NewObjectID()
+	current := &model.Product{ID: productID, Name: "Laptop"}
 
-	update := bson.M{
-		"name":        "New Name",
-		"description": "New Description",
+	mockRepo.On("FindByID", mock.Anything, productID).Return(current, nil)
+
+	// Act
+	product, err := service.PatchProduct(ctx, productID, nil, []byte(`{}`))
+
+	// Assert - Regla de negocio: Un parche vacío no modifica nada
+	assert.ErrorIs(t, err, ErrProductUnchanged)
+	assert.Equal(t, current, product)
+	mockRepo.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductService_PatchProduct_InvalidPatch(t *testing.T) {
+	cases := map[string]string{
+		"not an object": `["name"]`,
+		"null document": `null`,
+		"unknown field": `{"color":"red"}`,
+		"read-only id":  `{"id":"507f1f77bcf86cd799439011"}`,
+		"read-only ver": `{"version":9}`,
+		"wrong type":    `{"price":"cheap"}`,
+	}
+
+	for name, patch := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockUpdateRepository)
+			service := NewProductService(mockRepo)
+
+			_, err := service.PatchProduct(context.Background(), primitive.NewObjectID(), nil, []byte(patch))
+
+			// Regla de negocio: Parches que no encajan en el modelo se rechazan
+			assert.ErrorIs(t, err, ErrInvalidPatch)
+			mockRepo.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
 	}
+}
+
+func TestProductService_PatchProduct_NotFound(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	mockRepo.On("FindByID", mock.Anything, productID).Return(nil, mongo.ErrNoDocuments)
+
+	// Act
+	product, err := service.PatchProduct(ctx, productID, nil, []byte(`{"name":"New Name","description":"New Description"}`))
+
+	// Assert - Regla de negocio: Actualizar producto inexistente debe reportarse como no encontrado
+	assert.ErrorIs(t, err, ErrProductNotFound, "Debe fallar si el producto no existe")
+	assert.Nil(t, product)
+	mockRepo.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_PatchProduct_Unchanged(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+	current := &model.Product{ID: productID, Name: "Same Name", Version: 4}
+
+	mockRepo.On("FindByID", mock.Anything, productID).Return(current, nil)
+
+	// Act
+	product, err := service.PatchProduct(ctx, productID, nil, []byte(`{"name":"Same Name"}`))
+
+	// Assert - Regla de negocio: Un parche sin cambios se informa junto con el producto actual
+	assert.ErrorIs(t, err, ErrProductUnchanged)
+	assert.Equal(t, current, product)
+	mockRepo.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductService_PatchProduct_ConcurrentWrite(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
 
-	mockRepo.On("UpdateByID", ctx, productID, update).Return(&mongo.UpdateResult{ModifiedCount: 0}, nil)
+	mockRepo.On("FindByID", mock.Anything, productID).Return(&model.Product{ID: productID, Name: "Laptop", Version: 2}, nil)
+	mockRepo.On("UpdateByID", mock.Anything, productID, int64(2), bson.M{"stock": 1}).Return(&mongo.UpdateResult{MatchedCount: 0}, nil)
 
 	// Act
-	err := service.UpdateProduct(ctx, productID, "New Name", "New Description")
+	product, err := service.PatchProduct(ctx, productID, []int64{2}, []byte(`{"stock":1}`))
 
-	// Assert - Regla de negocio: Actualizar producto inexistente no genera error
-	assert.NoError(t, err, "No debe fallar si el producto no existe")
+	// Assert - Regla de negocio: Si otro cliente escribió entre la lectura y la escritura, se rechaza
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.Nil(t, product)
 	mockRepo.AssertExpectations(t)
 }
 
-func TestProductService_UpdateProduct_DatabaseError(t *testing.T) {
+func TestProductService_PatchProduct_DatabaseError(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUpdateRepository)
 	service := NewProductService(mockRepo)
@@ -128,13 +384,243 @@ func TestProductService_UpdateProduct_DatabaseError(t *testing.T) {
 		"description": "New Description",
 	}
 
-	mockRepo.On("UpdateByID", ctx, productID, update).Return(nil, errors.New("fallo de escritura"))
+	mockRepo.On("FindByID", mock.Anything, productID).Return(&model.Product{ID: productID, Name: "Old Name", Version: 1}, nil)
+	mockRepo.On("UpdateByID", mock.Anything, productID, int64(1), update).Return(nil, errors.New("fallo de escritura"))
 
 	// Act
-	err := service.UpdateProduct(ctx, productID, "New Name", "New Description")
+	_, err := service.PatchProduct(ctx, productID, nil, []byte(`{"name":"New Name","description":"New Description"}`))
 
 	// Assert - Regla de negocio: Errores de BD deben propagarse
 	assert.Error(t, err, "Debe retornar error de base de datos")
 	assert.Contains(t, err.Error(), "escritura")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestProductService_PatchMany_UnorderedPartialFailure(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	found, missing := primitive.NewObjectID(), primitive.NewObjectID()
+
+	items := []BulkPatchItem{
+		{ID: found.Hex(), Patch: []byte(`{"stock":5}`)},
+		{ID: "not-an-id", Patch: []byte(`{"stock":5}`)},
+		{ID: missing.Hex(), Patch: []byte(`{"stock":5}`)},
+		{ID: found.Hex(), Patch: []byte(`{"price":-1}`)},
+	}
+
+	mockRepo.On("FindByIDs", mock.Anything, []primitive.ObjectID{found, missing}).Return(map[primitive.ObjectID]model.Product{found: {ID: found}}, nil)
+	mockRepo.On("UpdateByID", mock.Anything, found, int64(0), bson.M{"stock": 5}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	// Act
+	res, err := service.PatchMany(ctx, items, false)
+
+	// Assert - Regla de negocio: Cada elemento reporta su propio resultado
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bulk.StatusSucceeded, bulk.StatusFailed, bulk.StatusFailed, bulk.StatusFailed}, statuses(res))
+	assert.Equal(t, "invalid id format", res.Items[1].Error)
+	assert.Equal(t, ErrProductNotFound.Error(), res.Items[2].Error)
+	assert.Equal(t, "price", res.Items[3].Fields[0].Field)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_PatchMany_OrderedStopsAtFirstFailure(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	first, missing, last := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	items := []BulkPatchItem{
+		{ID: first.Hex(), Patch: []byte(`{"name":"Laptop Pro"}`)},
+		{ID: missing.Hex(), Patch: []byte(`{"name":"Ghost"}`)},
+		{ID: last.Hex(), Patch: []byte(`{"name":"Mouse Pro"}`)},
+	}
+
+	mockRepo.On("FindByIDs", mock.Anything, []primitive.ObjectID{first, missing, last}).Return(map[primitive.ObjectID]model.Product{first: {ID: first}, last: {ID: last}}, nil)
+	mockRepo.On("UpdateByID", mock.Anything, first, int64(0), bson.M{"name": "Laptop Pro"}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	// Act
+	res, err := service.PatchMany(ctx, items, true)
+
+	// Assert - Regla de negocio: En modo ordenado nada después del primer fallo se escribe
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bulk.StatusSucceeded, bulk.StatusFailed, bulk.StatusSkipped}, statuses(res))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_PatchMany_WriteError(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+
+	items := []BulkPatchItem{
+		{ID: a.Hex(), Patch: []byte(`{"stock":1}`)},
+		{ID: b.Hex(), Patch: []byte(`{"stock":2}`)},
+	}
+	writeErr := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 121, Message: "document failed validation"}}}
+
+	mockRepo.On("FindByIDs", mock.Anything, []primitive.ObjectID{a, b}).Return(map[primitive.ObjectID]model.Product{a: {ID: a}, b: {ID: b}}, nil)
+	mockRepo.On("UpdateByID", mock.Anything, a, int64(0), bson.M{"stock": 1}).Return(nil, writeErr)
+	mockRepo.On("UpdateByID", mock.Anything, b, int64(0), bson.M{"stock": 2}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	// Act
+	res, err := service.PatchMany(ctx, items, false)
+
+	// Assert - Regla de negocio: Los errores de escritura se asignan al elemento que falló
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bulk.StatusFailed, bulk.StatusSucceeded}, statuses(res))
+	assert.Equal(t, "document failed validation", res.Items[0].Error)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_PatchMany_ConcurrentWrite(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	revisions := repository.NewMemoryRevisionRepository()
+	store := audit.NewMemoryStore()
+	service := NewProductService(mockRepo)
+	service.Revisions = revisions
+	service.Audit = audit.NewRecorder(store)
+	ctx := context.Background()
+	raced, kept := primitive.NewObjectID(), primitive.NewObjectID()
+
+	items := []BulkPatchItem{
+		{ID: raced.Hex(), Patch: []byte(`{"stock":1}`)},
+		{ID: kept.Hex(), Patch: []byte(`{"stock":2}`)},
+	}
+
+	mockRepo.On("FindByIDs", mock.Anything, []primitive.ObjectID{raced, kept}).Return(map[primitive.ObjectID]model.Product{
+		raced: {ID: raced, Version: 4},
+		kept:  {ID: kept, Version: 7},
+	}, nil)
+	mockRepo.On("UpdateByID", mock.Anything, raced, int64(4), bson.M{"stock": 1}).Return(&mongo.UpdateResult{}, nil)
+	mockRepo.On("UpdateByID", mock.Anything, kept, int64(7), bson.M{"stock": 2}).Return(&mongo.UpdateResult{MatchedCount: 1}, nil)
+
+	// Act
+	res, err := service.PatchMany(ctx, items, false)
+
+	// Assert - Regla de negocio: Un producto escrito por otro entre la lectura y la escritura falla sin auditoría
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bulk.StatusFailed, bulk.StatusSucceeded}, statuses(res))
+	assert.Equal(t, ErrVersionMismatch.Error(), res.Items[0].Error)
+	keptRevisions, _ := revisions.FindRevisions(ctx, kept, 0, 10)
+	assert.Len(t, keptRevisions, 1)
+	racedHistory, _ := store.History(ctx, raced, primitive.NilObjectID, 10)
+	keptHistory, _ := store.History(ctx, kept, primitive.NilObjectID, 10)
+	assert.Empty(t, racedHistory)
+	if assert.Len(t, keptHistory, 1) {
+		assert.Equal(t, int64(8), keptHistory[0].Version)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+// failingRevisions refuses every revision write.
+type failingRevisions struct {
+	*repository.MemoryRevisionRepository
+}
+
+func (failingRevisions) SaveRevisions(context.Context, ...repository.Revision) error {
+	return errors.New("fallo de escritura de revisiones")
+}
+
+func TestProductService_PatchMany_SnapshotError(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	service.Revisions = failingRevisions{repository.NewMemoryRevisionRepository()}
+	ctx := context.Background()
+	id := primitive.NewObjectID()
+
+	mockRepo.On("FindByIDs", mock.Anything, []primitive.ObjectID{id}).Return(map[primitive.ObjectID]model.Product{id: {ID: id, Version: 2}}, nil)
+
+	// Act
+	res, err := service.PatchMany(ctx, []BulkPatchItem{{ID: id.Hex(), Patch: []byte(`{"stock":1}`)}}, false)
+
+	// Assert - Regla de negocio: Sin revisión guardada el producto no se modifica
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bulk.StatusFailed}, statuses(res))
+	mockRepo.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestProductService_PatchMany_DatabaseError(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("FindByIDs", mock.Anything, mock.Anything).Return(nil, errors.New("fallo de lectura"))
+
+	// Act
+	res, err := service.PatchMany(ctx, []BulkPatchItem{{ID: primitive.NewObjectID().Hex(), Patch: []byte(`{}`)}}, true)
+
+	// Assert - Regla de negocio: Errores de BD deben propagarse
+	assert.Error(t, err)
+	assert.Nil(t, res)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_RollbackProduct(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	revisions := repository.NewMemoryRevisionRepository()
+	store := audit.NewMemoryStore()
+	service := NewProductService(mockRepo)
+	service.Revisions = revisions
+	service.Audit = audit.NewRecorder(store)
+	ctx := context.Background()
+	productID := primitive.NewObjectID()
+
+	original := model.Product{ID: productID, Name: "Laptop", Price: 1500.00, Stock: 10, Version: 1}
+	current := &model.Product{ID: productID, Name: "Laptop", Price: 900.00, Stock: 10, Version: 2}
+	restored := model.Product{Name: "Laptop", Price: 1500.00, Stock: 10, Version: 3}
+	stored := restored
+	stored.ID = productID
+	_ = revisions.SaveRevisions(ctx, repository.Revision{ProductID: productID, Version: 1, Product: original})
+
+	mockRepo.On("FindByID", mock.Anything, productID).Return(current, nil).Once()
+	mockRepo.On("ReplaceByID", mock.Anything, productID, int64(2), restored).Return(&mongo.UpdateResult{MatchedCount: 1, ModifiedCount: 1}, nil)
+	mockRepo.On("FindByID", mock.Anything, productID).Return(&stored, nil).Once()
+
+	// Act
+	updated, err := service.RollbackProduct(ctx, productID, nil, 1)
+
+	// Assert - Regla de negocio: Revertir crea una versión nueva y guarda la que reemplaza
+	assert.NoError(t, err)
+	assert.Equal(t, &stored, updated)
+	saved, err := revisions.FindRevision(ctx, productID, 2)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 900.00, saved.Product.Price)
+	}
+	entries, _ := store.History(ctx, productID, primitive.NilObjectID, 10)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, audit.ActionRollback, entries[0].Action)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductService_RollbackProduct_UnknownRevision(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUpdateRepository)
+	service := NewProductService(mockRepo)
+	service.Revisions = repository.NewMemoryRevisionRepository()
+
+	// Act
+	updated, err := service.RollbackProduct(context.Background(), primitive.NewObjectID(), nil, 7)
+
+	// Assert - Regla de negocio: Solo se puede revertir a versiones guardadas
+	assert.ErrorIs(t, err, ErrRevisionNotFound)
+	assert.Nil(t, updated)
+	mockRepo.AssertNotCalled(t, "ReplaceByID", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func statuses(res *bulk.Result) []string {
+	var out []string
+	for _, item := range res.Items {
+		out = append(out, item.Status)
+	}
+	return out
+}